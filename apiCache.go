@@ -0,0 +1,250 @@
+package banchogo
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheBackend persists API cache entries, e.g. to survive restarts. Values are JSON encoded.
+type CacheBackend interface {
+	Get(key string) (data []byte, expires time.Time, ok bool)
+	Set(key string, data []byte, expires time.Time) error
+	Delete(key string) error
+}
+
+type APICacheOptions struct {
+	// TTL is how long fetched data is reused, 5 minutes by default. Negative TTL disables caching,
+	// concurrent lookups of the same data are still coalesced.
+	TTL time.Duration
+	// Backend is an optional persistent storage, entries are always kept in memory as well
+	Backend CacheBackend
+}
+
+// APICache caches API lookups per user and mode and coalesces concurrent lookups into a single request
+type APICache struct {
+	ttl     time.Duration
+	backend CacheBackend
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]apiCacheEntry
+	calls   map[string]*apiCacheCall
+}
+
+type apiCacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+type apiCacheCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func NewAPICache(opt APICacheOptions) *APICache {
+	if opt.TTL == 0 {
+		opt.TTL = 5 * time.Minute
+	}
+	return &APICache{
+		ttl:     opt.TTL,
+		backend: opt.Backend,
+		now:     time.Now,
+		entries: make(map[string]apiCacheEntry),
+		calls:   make(map[string]*apiCacheCall),
+	}
+}
+
+// cachedFetch returns a cached value for the key or calls fetch. Errors are not cached.
+func cachedFetch[T any](c *APICache, key string, fetch func() (T, error)) (T, error) {
+	var zero T
+	if c == nil {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.value.(T), nil
+	}
+
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		if call.err != nil {
+			return zero, call.err
+		}
+		return call.value.(T), nil
+	}
+
+	call := &apiCacheCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	if v, ok := loadCached[T](c, key, call); ok {
+		call.value = v
+		return v, nil
+	}
+
+	v, err := fetch()
+	if err != nil {
+		call.err = err
+		return zero, err
+	}
+	call.value = v
+	c.store(key, call, v)
+	return v, nil
+}
+
+// loadCached reads an entry from the backend and keeps it in memory
+func loadCached[T any](c *APICache, key string, call *apiCacheCall) (v T, ok bool) {
+	if c.backend == nil || c.ttl < 0 {
+		return v, false
+	}
+	data, expires, found := c.backend.Get(key)
+	if !found || !c.now().Before(expires) || json.Unmarshal(data, &v) != nil {
+		return v, false
+	}
+
+	c.mu.Lock()
+	if c.calls[key] == call {
+		c.entries[key] = apiCacheEntry{value: v, expires: expires}
+	}
+	c.mu.Unlock()
+	return v, true
+}
+
+// store keeps the fetched value unless the key was invalidated while the call was running
+func (c *APICache) store(key string, call *apiCacheCall, v any) {
+	if c.ttl < 0 {
+		return
+	}
+	expires := c.now().Add(c.ttl)
+
+	c.mu.Lock()
+	current := c.calls[key] == call
+	if current {
+		c.entries[key] = apiCacheEntry{value: v, expires: expires}
+	}
+	c.mu.Unlock()
+
+	if c.backend == nil || !current {
+		return
+	}
+	if data, err := json.Marshal(v); err == nil {
+		_ = c.backend.Set(key, data, expires)
+	}
+	// InvalidateUser could delete the key from the backend before it was set
+	c.mu.Lock()
+	current = c.calls[key] == call
+	c.mu.Unlock()
+	if !current {
+		_ = c.backend.Delete(key)
+	}
+}
+
+// userCacheModes are mode parts of user cache keys, "default" is used when mode isn't specified
+var userCacheModes = [...]string{"0", "1", "2", "3", "default"}
+
+func userCacheKey(username, api, mode string) string {
+	return "user:" + strings.ToLower(strings.ReplaceAll(username, " ", "_")) + ":" + api + ":" + mode
+}
+
+// InvalidateUser removes all cached data of the user, so the next lookup hits the API
+func (c *APICache) InvalidateUser(username string) {
+	var keys []string
+	for _, api := range [...]string{"v1", "v2"} {
+		for _, mode := range userCacheModes {
+			keys = append(keys, userCacheKey(username, api, mode))
+		}
+	}
+
+	// Running calls are forgotten, so they don't store stale values when they finish
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.entries, key)
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+
+	// Backend I/O is done without the lock, so readers of memory entries don't wait for it
+	if c.backend != nil {
+		for _, key := range keys {
+			_ = c.backend.Delete(key)
+		}
+	}
+}
+
+// Clear removes all entries from memory, the backend is left untouched
+func (c *APICache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]apiCacheEntry)
+	c.mu.Unlock()
+}
+
+// FileCacheBackend stores every entry in its own file in Dir
+type FileCacheBackend struct {
+	Dir string
+}
+
+type fileCacheEntry struct {
+	Expires time.Time       `json:"expires"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (f *FileCacheBackend) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (f *FileCacheBackend) Get(key string) ([]byte, time.Time, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	var e fileCacheEntry
+	if json.Unmarshal(data, &e) != nil {
+		return nil, time.Time{}, false
+	}
+	return e.Data, e.Expires, true
+}
+
+func (f *FileCacheBackend) Set(key string, data []byte, expires time.Time) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(fileCacheEntry{Expires: expires, Data: data})
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path(key) + ".tmp"
+	if err = os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(key))
+}
+
+func (f *FileCacheBackend) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package banchogo
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPICache_Coalescing(t *testing.T) {
+	c := NewAPICache(APICacheOptions{TTL: time.Minute})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var calls int32
+	release := make(chan struct{})
+	fetch := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := cachedFetch(c, "key", fetch); err != nil || v != 42 {
+				t.Errorf("unexpected result %d, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("concurrent lookups should be coalesced, got %d calls", calls)
+	}
+
+	cachedFetch(c, "key", fetch)
+	now = now.Add(2 * time.Minute)
+	cachedFetch(c, "key", fetch)
+	if calls != 2 {
+		t.Errorf("expected a single refetch after ttl, got %d calls", calls)
+	}
+
+	if _, err := cachedFetch(c, "error", func() (int, error) { return 0, ErrUserNotFound }); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected error, got %v", err)
+	}
+	if v, _ := cachedFetch(c, "error", func() (int, error) { return 1, nil }); v != 1 {
+		t.Error("errors shouldn't be cached")
+	}
+}
+
+func TestAPICache_FileBackend(t *testing.T) {
+	backend := &FileCacheBackend{Dir: t.TempDir()}
+	key := userCacheKey("Some Player", "v1", "0")
+
+	c := NewAPICache(APICacheOptions{Backend: backend})
+	cachedFetch(c, key, func() (string, error) { return "stored", nil })
+
+	// A new cache instance, e.g. after restart, reads the entry from the backend
+	c = NewAPICache(APICacheOptions{Backend: backend})
+	v, err := cachedFetch(c, key, func() (string, error) { return "fetched", nil })
+	if err != nil || v != "stored" {
+		t.Errorf("expected value from backend, got %q (%v)", v, err)
+	}
+
+	c.InvalidateUser("some_player")
+	if _, _, ok := backend.Get(key); ok {
+		t.Error("entry should be removed from the backend")
+	}
+	if v, _ = cachedFetch(c, key, func() (string, error) { return "fetched", nil }); v != "fetched" {
+		t.Errorf("expected fresh value after invalidation, got %q", v)
+	}
+}
+
+func TestAPICache_InvalidateDuringFetch(t *testing.T) {
+	backend := &FileCacheBackend{Dir: t.TempDir()}
+	c := NewAPICache(APICacheOptions{TTL: time.Minute, Backend: backend})
+	key := userCacheKey("peppy", "v1", "0")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan int)
+	go func() {
+		v, _ := cachedFetch(c, key, func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- v
+	}()
+	<-started
+	c.InvalidateUser("peppy")
+	close(release)
+
+	// The caller of the stale fetch still gets its value, but it isn't cached
+	if v := <-done; v != 1 {
+		t.Errorf("unexpected stale result %d", v)
+	}
+	if _, _, ok := backend.Get(key); ok {
+		t.Error("stale value shouldn't be saved to the backend")
+	}
+	if v, _ := cachedFetch(c, key, func() (int, error) { return 2, nil }); v != 2 {
+		t.Errorf("lookup after invalidation should fetch again, got %d", v)
+	}
+}
@@ -0,0 +1,48 @@
+package banchogo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/robloxxa/banchogo/apiv2"
+	"github.com/thehowl/go-osuapi"
+)
+
+var ErrApiV2NotConfigured = errors.New("osu! api v2 client is not configured")
+
+// FetchMatch returns lobby history from API v2, it includes games played before the client joined the lobby
+func (l *Lobby) FetchMatch(opt ...apiv2.MatchOptions) (*apiv2.MatchResponse, error) {
+	if l.Client.ApiV2 == nil {
+		return nil, ErrApiV2NotConfigured
+	}
+	return l.Client.ApiV2.GetMatch(context.Background(), l.Id, opt...)
+}
+
+// legacyUser converts API v2 user to the legacy API format used by User.Data
+func legacyUser(u *apiv2.User) *osuapi.User {
+	data := &osuapi.User{
+		UserID:   u.ID,
+		Username: u.Username,
+		Date:     osuapi.MySQLDate(u.JoinDate),
+		Country:  u.CountryCode,
+	}
+	if s := u.Statistics; s != nil {
+		data.Count300 = s.Count300
+		data.Count100 = s.Count100
+		data.Count50 = s.Count50
+		data.Playcount = s.PlayCount
+		data.RankedScore = s.RankedScore
+		data.TotalScore = s.TotalScore
+		data.Rank = s.GlobalRank
+		data.Level = float64(s.Level.Current) + float64(s.Level.Progress)/100
+		data.PP = s.PP
+		data.Accuracy = s.HitAccuracy
+		data.CountSS = s.GradeCounts.SS
+		data.CountSSH = s.GradeCounts.SSH
+		data.CountS = s.GradeCounts.S
+		data.CountSH = s.GradeCounts.SH
+		data.CountA = s.GradeCounts.A
+		data.CountryRank = s.CountryRank
+	}
+	return data
+}
@@ -0,0 +1,40 @@
+package banchogo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robloxxa/banchogo/apiv2"
+)
+
+func TestUser_FetchFromAPIv2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.Write([]byte(`{"access_token":"token","expires_in":86400}`))
+		case "/api/v2/users/Some_Player/osu":
+			w.Write([]byte(`{"id":3,"username":"Some Player","country_code":"RU","statistics":{"pp":1234.5,"global_rank":42,"level":{"current":100,"progress":50}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	b := NewBanchoClient(ClientOptions{Username: "bot", ApiV2: apiv2.New(apiv2.Options{BaseURL: server.URL})})
+
+	data, err := b.GetUser("Some Player").FetchFromAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.UserID != 3 || data.PP != 1234.5 || data.Rank != 42 || data.Level != 100.5 || data.Country != "RU" {
+		t.Errorf("unexpected user data %+v", data)
+	}
+	if b.GetUser("Some Player").Data().UserID != 3 {
+		t.Error("user data wasn't stored")
+	}
+
+	if _, err = b.GetLobby(1).FetchMatch(); err == nil {
+		t.Error("expected an error for unknown match")
+	}
+}
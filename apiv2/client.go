@@ -0,0 +1,117 @@
+// Package apiv2 is a client for osu! API v2 https://osu.ppy.sh/docs/index.html.
+//
+// Tokens are requested with the client credentials flow by default. To act on behalf of a user
+// send them to AuthCodeURL and pass the returned code to Exchange. Expired tokens are refreshed automatically.
+package apiv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const DefaultBaseURL = "https://osu.ppy.sh"
+
+type Options struct {
+	ClientID     int
+	ClientSecret string
+	// RedirectURI is required only for the authorization code flow
+	RedirectURI string
+	// Scopes are requested token scopes, "public" by default
+	Scopes []string
+
+	// BaseURL is the osu! website url, DefaultBaseURL by default
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// OnToken is called after a token is obtained or refreshed, e.g. to persist a user token
+	OnToken func(Token)
+}
+
+// APIError is returned when API responds with a non 2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("osu! api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("osu! api: %d %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	opt Options
+
+	mu    sync.Mutex
+	token *Token
+}
+
+func New(opt Options) *Client {
+	if opt.BaseURL == "" {
+		opt.BaseURL = DefaultBaseURL
+	}
+	opt.BaseURL = strings.TrimRight(opt.BaseURL, "/")
+	if len(opt.Scopes) == 0 {
+		opt.Scopes = []string{"public"}
+	}
+	if opt.HTTPClient == nil {
+		opt.HTTPClient = http.DefaultClient
+	}
+	return &Client{opt: opt}
+}
+
+// get requests path relative to /api/v2 and decodes response json into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	token, err := c.validToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	u := c.opt.BaseURL + "/api/v2" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-api-version", "20220705")
+
+	return c.do(req, v)
+}
+
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.opt.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+
+		var msg struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &msg) == nil {
+			apiErr.Message = msg.Error
+			if msg.ErrorDescription != "" {
+				apiErr.Message = msg.ErrorDescription
+			}
+		}
+		return apiErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,121 @@
+package apiv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, grants *[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != "1" || r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		*grants = append(*grants, r.Form.Get("grant_type"))
+
+		resp := map[string]any{"access_token": "token-" + r.Form.Get("grant_type"), "token_type": "Bearer", "expires_in": 86400}
+		if r.Form.Get("grant_type") == "authorization_code" {
+			resp["refresh_token"] = "refresh"
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/api/v2/users/peppy/taiko", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" || r.URL.Query().Get("key") != "username" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":2,"username":"peppy","country_code":"AU","statistics":{"pp":1.5,"global_rank":100}}`))
+	})
+	mux.HandleFunc("/api/v2/matches/123", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") != "10" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"match":{"id":123,"name":"test"},"events":[{"id":11,"detail":{"type":"other"},"game":{"beatmap_id":75,"scores":[{"user_id":2,"score":1000,"match":{"slot":0,"team":"red","pass":true}}]}}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_ClientCredentials(t *testing.T) {
+	var grants []string
+	server := newTestServer(t, &grants)
+	c := New(Options{ClientID: 1, ClientSecret: "secret", BaseURL: server.URL + "/"})
+
+	u, err := c.GetUserByName(context.Background(), "peppy", ModeTaiko)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 2 || u.Statistics == nil || u.Statistics.PP != 1.5 {
+		t.Errorf("unexpected user %+v", u)
+	}
+
+	m, err := c.GetMatch(context.Background(), 123, MatchOptions{After: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Events) != 1 || m.Events[0].Game == nil || m.Events[0].Game.Scores[0].Match.Team != "red" {
+		t.Errorf("unexpected match %+v", m)
+	}
+
+	if len(grants) != 1 || grants[0] != "client_credentials" {
+		t.Errorf("token should be requested once, got %v", grants)
+	}
+
+	var apiErr *APIError
+	if _, err = c.GetBeatmap(context.Background(), 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_AuthorizationCode(t *testing.T) {
+	var grants []string
+	server := newTestServer(t, &grants)
+
+	var saved []Token
+	c := New(Options{
+		ClientID:     1,
+		ClientSecret: "secret",
+		RedirectURI:  "http://localhost/callback",
+		BaseURL:      server.URL,
+		Scopes:       []string{"public", "identify"},
+		OnToken:      func(t Token) { saved = append(saved, t) },
+	})
+
+	authURL, err := url.Parse(c.AuthCodeURL("xyz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := authURL.Query(); authURL.Path != "/oauth/authorize" || q.Get("scope") != "public identify" || q.Get("state") != "xyz" {
+		t.Errorf("unexpected auth url %s", authURL)
+	}
+
+	if _, err = c.Exchange(context.Background(), "code"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An expired user token is refreshed instead of falling back to client credentials
+	token, _ := c.Token()
+	token.ExpiresAt = time.Now()
+	c.SetToken(token)
+
+	if _, err = c.GetUserByName(context.Background(), "peppy", ModeTaiko); err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 2 || grants[1] != "refresh_token" {
+		t.Errorf("expected token refresh, got %v", grants)
+	}
+	if len(saved) != 2 || saved[1].RefreshToken != "refresh" {
+		t.Errorf("refreshed token should keep the refresh token, got %+v", saved)
+	}
+}
@@ -0,0 +1,129 @@
+package apiv2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// GetUser returns a user by id, mode is optional and defaults to user's default mode
+func (c *Client) GetUser(ctx context.Context, id int, mode ...Mode) (*User, error) {
+	return c.getUser(ctx, strconv.Itoa(id), "id", mode)
+}
+
+// GetUserByName returns a user by username
+func (c *Client) GetUserByName(ctx context.Context, username string, mode ...Mode) (*User, error) {
+	return c.getUser(ctx, username, "username", mode)
+}
+
+func (c *Client) getUser(ctx context.Context, user, key string, mode []Mode) (*User, error) {
+	path := "/users/" + url.PathEscape(user)
+	if len(mode) > 0 && mode[0] != "" {
+		path += "/" + string(mode[0])
+	}
+
+	var u User
+	if err := c.get(ctx, path, url.Values{"key": {key}}, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (c *Client) GetBeatmap(ctx context.Context, id int) (*Beatmap, error) {
+	var b Beatmap
+	if err := c.get(ctx, fmt.Sprintf("/beatmaps/%d", id), nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (c *Client) GetBeatmapset(ctx context.Context, id int) (*Beatmapset, error) {
+	var s Beatmapset
+	if err := c.get(ctx, fmt.Sprintf("/beatmapsets/%d", id), nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetBeatmapScores returns top scores on a beatmap, mode is optional
+func (c *Client) GetBeatmapScores(ctx context.Context, beatmapID int, mode ...Mode) ([]Score, error) {
+	query := url.Values{}
+	if len(mode) > 0 && mode[0] != "" {
+		query.Set("mode", string(mode[0]))
+	}
+
+	var resp struct {
+		Scores []Score `json:"scores"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/beatmaps/%d/scores", beatmapID), query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Scores, nil
+}
+
+type ScoreType string
+
+const (
+	ScoresBest   ScoreType = "best"
+	ScoresFirsts ScoreType = "firsts"
+	ScoresRecent ScoreType = "recent"
+)
+
+type UserScoresOptions struct {
+	Mode         Mode
+	Limit        int
+	Offset       int
+	IncludeFails bool
+}
+
+func (c *Client) GetUserScores(ctx context.Context, userID int, typ ScoreType, opt UserScoresOptions) ([]Score, error) {
+	query := url.Values{}
+	if opt.Mode != "" {
+		query.Set("mode", string(opt.Mode))
+	}
+	if opt.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opt.Limit))
+	}
+	if opt.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opt.Offset))
+	}
+	if opt.IncludeFails {
+		query.Set("include_fails", "1")
+	}
+
+	var scores []Score
+	if err := c.get(ctx, fmt.Sprintf("/users/%d/scores/%s", userID, typ), query, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// MatchOptions pages through match events, Before and After are event ids
+type MatchOptions struct {
+	Before int64
+	After  int64
+	Limit  int
+}
+
+// GetMatch returns a multiplayer match with its events, match id is the same as Lobby.Id
+func (c *Client) GetMatch(ctx context.Context, matchID int, opt ...MatchOptions) (*MatchResponse, error) {
+	query := url.Values{}
+	if len(opt) > 0 {
+		if opt[0].Before > 0 {
+			query.Set("before", strconv.FormatInt(opt[0].Before, 10))
+		}
+		if opt[0].After > 0 {
+			query.Set("after", strconv.FormatInt(opt[0].After, 10))
+		}
+		if opt[0].Limit > 0 {
+			query.Set("limit", strconv.Itoa(opt[0].Limit))
+		}
+	}
+
+	var m MatchResponse
+	if err := c.get(ctx, fmt.Sprintf("/matches/%d", matchID), query, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
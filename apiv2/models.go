@@ -0,0 +1,186 @@
+package apiv2
+
+import "time"
+
+// Mode is a ruleset name used by API v2
+type Mode string
+
+const (
+	ModeOsu    Mode = "osu"
+	ModeTaiko  Mode = "taiko"
+	ModeFruits Mode = "fruits"
+	ModeMania  Mode = "mania"
+)
+
+// Modes are indexed by legacy mode ids, so Modes[osuapi.ModeTaiko] is ModeTaiko
+var Modes = [...]Mode{ModeOsu, ModeTaiko, ModeFruits, ModeMania}
+
+type Country struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type GradeCounts struct {
+	SS  int `json:"ss"`
+	SSH int `json:"ssh"`
+	S   int `json:"s"`
+	SH  int `json:"sh"`
+	A   int `json:"a"`
+}
+
+type UserLevel struct {
+	Current  int `json:"current"`
+	Progress int `json:"progress"`
+}
+
+type UserStatistics struct {
+	Level                  UserLevel   `json:"level"`
+	GlobalRank             int         `json:"global_rank"`
+	CountryRank            int         `json:"country_rank"`
+	PP                     float64     `json:"pp"`
+	RankedScore            int64       `json:"ranked_score"`
+	TotalScore             int64       `json:"total_score"`
+	HitAccuracy            float64     `json:"hit_accuracy"`
+	PlayCount              int         `json:"play_count"`
+	PlayTime               int         `json:"play_time"`
+	TotalHits              int         `json:"total_hits"`
+	MaximumCombo           int         `json:"maximum_combo"`
+	GradeCounts            GradeCounts `json:"grade_counts"`
+	Count300               int         `json:"count_300"`
+	Count100               int         `json:"count_100"`
+	Count50                int         `json:"count_50"`
+	CountMiss              int         `json:"count_miss"`
+	IsRanked               bool        `json:"is_ranked"`
+	ReplaysWatchedByOthers int         `json:"replays_watched_by_others"`
+}
+
+type User struct {
+	ID          int             `json:"id"`
+	Username    string          `json:"username"`
+	CountryCode string          `json:"country_code"`
+	Country     *Country        `json:"country"`
+	AvatarURL   string          `json:"avatar_url"`
+	IsActive    bool            `json:"is_active"`
+	IsBot       bool            `json:"is_bot"`
+	IsOnline    bool            `json:"is_online"`
+	IsSupporter bool            `json:"is_supporter"`
+	JoinDate    time.Time       `json:"join_date"`
+	Playmode    Mode            `json:"playmode"`
+	Statistics  *UserStatistics `json:"statistics"`
+}
+
+type Beatmapset struct {
+	ID            int        `json:"id"`
+	Artist        string     `json:"artist"`
+	ArtistUnicode string     `json:"artist_unicode"`
+	Title         string     `json:"title"`
+	TitleUnicode  string     `json:"title_unicode"`
+	Creator       string     `json:"creator"`
+	UserID        int        `json:"user_id"`
+	Source        string     `json:"source"`
+	Status        string     `json:"status"`
+	BPM           float64    `json:"bpm"`
+	RankedDate    *time.Time `json:"ranked_date"`
+	Beatmaps      []Beatmap  `json:"beatmaps"`
+}
+
+type Beatmap struct {
+	ID               int         `json:"id"`
+	BeatmapsetID     int         `json:"beatmapset_id"`
+	Version          string      `json:"version"`
+	Mode             Mode        `json:"mode"`
+	Status           string      `json:"status"`
+	DifficultyRating float64     `json:"difficulty_rating"`
+	TotalLength      int         `json:"total_length"`
+	HitLength        int         `json:"hit_length"`
+	BPM              float64     `json:"bpm"`
+	CS               float64     `json:"cs"`
+	AR               float64     `json:"ar"`
+	OD               float64     `json:"accuracy"`
+	HP               float64     `json:"drain"`
+	MaxCombo         int         `json:"max_combo"`
+	CountCircles     int         `json:"count_circles"`
+	CountSliders     int         `json:"count_sliders"`
+	CountSpinners    int         `json:"count_spinners"`
+	Checksum         string      `json:"checksum"`
+	URL              string      `json:"url"`
+	Beatmapset       *Beatmapset `json:"beatmapset"`
+}
+
+type ScoreStatistics struct {
+	Count300  int `json:"count_300"`
+	Count100  int `json:"count_100"`
+	Count50   int `json:"count_50"`
+	CountGeki int `json:"count_geki"`
+	CountKatu int `json:"count_katu"`
+	CountMiss int `json:"count_miss"`
+}
+
+type Score struct {
+	ID         int64           `json:"id"`
+	UserID     int             `json:"user_id"`
+	Accuracy   float64         `json:"accuracy"`
+	Mods       []string        `json:"mods"`
+	Score      int             `json:"score"`
+	MaxCombo   int             `json:"max_combo"`
+	Passed     bool            `json:"passed"`
+	Perfect    bool            `json:"perfect"`
+	PP         *float64        `json:"pp"`
+	Rank       string          `json:"rank"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Mode       Mode            `json:"mode"`
+	Statistics ScoreStatistics `json:"statistics"`
+	Beatmap    *Beatmap        `json:"beatmap"`
+	Beatmapset *Beatmapset     `json:"beatmapset"`
+	User       *User           `json:"user"`
+
+	// Match is set only for scores in multiplayer games
+	Match *ScoreMatch `json:"match"`
+}
+
+type ScoreMatch struct {
+	Slot   int    `json:"slot"`
+	Team   string `json:"team"`
+	Passed bool   `json:"pass"`
+}
+
+type Match struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+}
+
+type MatchGame struct {
+	ID          int        `json:"id"`
+	BeatmapID   int        `json:"beatmap_id"`
+	Beatmap     *Beatmap   `json:"beatmap"`
+	Mode        Mode       `json:"mode"`
+	ModeInt     int        `json:"mode_int"`
+	Mods        []string   `json:"mods"`
+	ScoringType string     `json:"scoring_type"`
+	TeamType    string     `json:"team_type"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Scores      []Score    `json:"scores"`
+}
+
+// MatchEvent is a multiplayer match history entry, Detail.Type is e.g. "match-created", "player-joined" or "other" for games
+type MatchEvent struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	UserID    *int      `json:"user_id"`
+	Detail    struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"detail"`
+	Game *MatchGame `json:"game"`
+}
+
+type MatchResponse struct {
+	Match         Match        `json:"match"`
+	Events        []MatchEvent `json:"events"`
+	Users         []User       `json:"users"`
+	FirstEventID  int64        `json:"first_event_id"`
+	LatestEventID int64        `json:"latest_event_id"`
+}
@@ -0,0 +1,131 @@
+package apiv2
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Token is an OAuth access token. RefreshToken is empty for client credentials tokens.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Valid reports whether the token exists and doesn't expire in the next minute
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && time.Until(t.ExpiresAt) > time.Minute
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// AuthCodeURL returns a url of the authorization page for the authorization code flow
+func (c *Client) AuthCodeURL(state string) string {
+	q := url.Values{
+		"client_id":     {strconv.Itoa(c.opt.ClientID)},
+		"redirect_uri":  {c.opt.RedirectURI},
+		"response_type": {"code"},
+		"scope":         {strings.Join(c.opt.Scopes, " ")},
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	return c.opt.BaseURL + "/oauth/authorize?" + q.Encode()
+}
+
+// Exchange trades an authorization code for a user token, the client uses it for all further requests
+func (c *Client) Exchange(ctx context.Context, code string) (Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.opt.RedirectURI},
+	})
+}
+
+// SetToken sets a previously obtained token, e.g. a user token loaded from storage
+func (c *Client) SetToken(t Token) {
+	c.mu.Lock()
+	c.token = &t
+	c.mu.Unlock()
+}
+
+// Token returns the current token, it may be expired
+func (c *Client) Token() (Token, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == nil {
+		return Token{}, false
+	}
+	return *c.token, true
+}
+
+// validToken returns the current token, refreshing it or requesting a client credentials token when needed
+func (c *Client) validToken(ctx context.Context) (Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.Valid() {
+		return *c.token, nil
+	}
+
+	if c.token != nil && c.token.RefreshToken != "" {
+		return c.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.token.RefreshToken},
+			"scope":         {strings.Join(c.opt.Scopes, " ")},
+		})
+	}
+
+	return c.requestToken(ctx, url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {strings.Join(c.opt.Scopes, " ")},
+	})
+}
+
+// requestToken must be called with c.mu locked
+func (c *Client) requestToken(ctx context.Context, form url.Values) (Token, error) {
+	form.Set("client_id", strconv.Itoa(c.opt.ClientID))
+	form.Set("client_secret", c.opt.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opt.BaseURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var resp tokenResponse
+	if err := c.do(req, &resp); err != nil {
+		return Token{}, err
+	}
+
+	token := Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		TokenType:    resp.TokenType,
+		ExpiresAt:    time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+	// Refresh token may be omitted when it wasn't rotated
+	if token.RefreshToken == "" && c.token != nil && form.Get("grant_type") == "refresh_token" {
+		token.RefreshToken = c.token.RefreshToken
+	}
+	c.token = &token
+
+	if c.opt.OnToken != nil {
+		c.opt.OnToken(token)
+	}
+	return token, nil
+}
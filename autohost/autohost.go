@@ -0,0 +1,416 @@
+// Package autohost implements host rotation for multiplayer lobbies.
+//
+// Players are put in a queue in order they join the lobby. After every finished match
+// the current host goes to the end of the queue and the next player in the queue gets host.
+package autohost
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/router"
+)
+
+var ErrMatchInProgress = errors.New("match is in progress")
+
+type Options struct {
+	// SkipRatio is a part of players (excluding host) that have to vote to skip the host, 0.5 by default
+	SkipRatio float64
+	// Rules are sent line by line in response to !rules command
+	Rules []string
+	// Store is used to persist host queue, queue isn't persisted if nil
+	Store Store
+	// Prefixes for lobby commands, "!" by default
+	Prefixes []string
+}
+
+type AutoHost struct {
+	mu sync.Mutex
+
+	Lobby  *banchogo.Lobby
+	Router *router.Router
+
+	queue       []*banchogo.User
+	skipVotes   map[*banchogo.User]struct{}
+	pendingHost *banchogo.User
+	// pendingID tells apart host changes, a timeout of an old one mustn't clear a newer one
+	pendingID int
+	// hostTimeout is how long BanchoBot has to transfer host before it's given again
+	hostTimeout time.Duration
+
+	skipRatio float64
+	rules     []string
+	store     Store
+	// unsaved is the latest queue snapshot the writer goroutine hasn't saved yet, saving is set while it runs
+	unsaved []string
+	saving  bool
+	saved   *sync.Cond
+
+	handlerRemovers []func()
+}
+
+// New creates auto host for a lobby and restores the queue from Options.Store. Call Start to begin rotation.
+func New(lobby *banchogo.Lobby, opt Options) (*AutoHost, error) {
+	a := &AutoHost{
+		Lobby:     lobby,
+		skipVotes: make(map[*banchogo.User]struct{}),
+		skipRatio: opt.SkipRatio,
+		rules:     opt.Rules,
+		store:     opt.Store,
+
+		hostTimeout: 10 * time.Second,
+	}
+	a.saved = sync.NewCond(&a.mu)
+	if a.skipRatio <= 0 {
+		a.skipRatio = 0.5
+	}
+
+	if a.store != nil {
+		names, err := a.store.Load(lobby.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			a.queue = append(a.queue, lobby.Client.GetUser(name))
+		}
+	}
+
+	a.Router = router.New(router.Options{
+		Prefixes: opt.Prefixes,
+		IsReferee: func(_ *banchogo.Channel, user *banchogo.User) bool {
+			return lobby.IsReferee(user)
+		},
+	})
+	a.Router.MustRegister(&router.Command{
+		Name:        "queue",
+		Aliases:     []string{"q"},
+		Description: "shows host queue",
+		Handler:     a.queueCommand,
+	})
+	a.Router.MustRegister(&router.Command{
+		Name:        "skip",
+		Description: "votes to skip the current host",
+		Handler:     a.skipCommand,
+	})
+	a.Router.MustRegister(&router.Command{
+		Name:        "rules",
+		Description: "shows lobby rules",
+		Handler:     a.rulesCommand,
+	})
+
+	return a, nil
+}
+
+// Start subscribes to lobby events and synchronizes the queue with lobby players
+func (a *AutoHost) Start() {
+	l := a.Lobby
+	a.handlerRemovers = []func(){
+		l.OnPlayerJoined(a.onPlayerJoined),
+		l.OnPlayerLeft(a.onPlayerLeft),
+		l.OnHost(a.onHost),
+		l.OnMatchStarted(a.onMatchStarted),
+		l.OnMatchFinished(func(*banchogo.MatchResult) {
+			a.Rotate()
+		}),
+		l.OnClosed(a.Stop),
+		a.Router.ListenChannel(l.Client, l.Channel),
+		l.Client.OnConnect(func() {
+			go func() {
+				if err := <-l.Channel.Join(); err == nil {
+					a.Sync()
+				}
+			}()
+		}),
+	}
+
+	go a.Sync()
+}
+
+// Stop unsubscribes from all events, queue stays saved in the store
+func (a *AutoHost) Stop() {
+	a.mu.Lock()
+	removers := a.handlerRemovers
+	a.handlerRemovers = nil
+	a.mu.Unlock()
+
+	for _, f := range removers {
+		f()
+	}
+}
+
+// Sync updates lobby settings and reconciles the queue with players actually in the lobby.
+// Players keep their position from the saved queue, new players go to the end in slot order.
+func (a *AutoHost) Sync() error {
+	if err := <-a.Lobby.UpdateSettings(); err != nil {
+		return err
+	}
+	players := a.Lobby.Players()
+
+	a.mu.Lock()
+	present := make(map[*banchogo.User]bool, len(players))
+	for _, p := range players {
+		present[p.User] = true
+	}
+
+	queue := make([]*banchogo.User, 0, len(players))
+	for _, u := range a.queue {
+		if present[u] {
+			queue = append(queue, u)
+			delete(present, u)
+		}
+	}
+	for _, p := range players {
+		if present[p.User] {
+			queue = append(queue, p.User)
+		}
+	}
+	a.queue = queue
+	a.save()
+	a.mu.Unlock()
+
+	a.ensureHost()
+	return nil
+}
+
+// Queue returns players in host order, the first one is the current host
+func (a *AutoHost) Queue() []*banchogo.User {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*banchogo.User(nil), a.queue...)
+}
+
+// Rotate moves the current host to the end of the queue and gives host to the next player
+func (a *AutoHost) Rotate() error {
+	if a.Lobby.IsPlaying() {
+		return ErrMatchInProgress
+	}
+
+	a.mu.Lock()
+	if len(a.queue) > 1 {
+		a.queue = append(a.queue[1:], a.queue[0])
+	}
+	a.skipVotes = make(map[*banchogo.User]struct{})
+	a.save()
+	a.mu.Unlock()
+
+	a.ensureHost()
+	return nil
+}
+
+func (a *AutoHost) onPlayerJoined(p *banchogo.LobbyPlayer) {
+	a.mu.Lock()
+	if a.indexOf(p.User) == -1 {
+		a.queue = append(a.queue, p.User)
+		a.save()
+	}
+	a.mu.Unlock()
+
+	if a.Lobby.Host() == nil {
+		a.ensureHost()
+	}
+}
+
+func (a *AutoHost) onPlayerLeft(p *banchogo.LobbyPlayer) {
+	a.mu.Lock()
+	i := a.indexOf(p.User)
+	if i != -1 {
+		a.queue = append(a.queue[:i], a.queue[i+1:]...)
+		a.save()
+	}
+	delete(a.skipVotes, p.User)
+	if a.pendingHost == p.User {
+		a.pendingHost = nil
+	}
+	a.mu.Unlock()
+
+	// Host left while picking a map, the next player in the queue should pick
+	if i == 0 && !a.Lobby.IsPlaying() {
+		a.ensureHost()
+	}
+}
+
+func (a *AutoHost) onHost(p *banchogo.LobbyPlayer) {
+	if p != nil {
+		a.clearPendingHost(p.User, 0)
+	}
+
+	// Bancho passes host to a random player when the host leaves,
+	// so the host is given back to whoever is next in the queue
+	a.ensureHost()
+}
+
+func (a *AutoHost) onMatchStarted() {
+	a.mu.Lock()
+	a.skipVotes = make(map[*banchogo.User]struct{})
+	a.mu.Unlock()
+}
+
+// ensureHost gives host to the first player in the queue if they aren't host yet
+func (a *AutoHost) ensureHost() {
+	host := a.Lobby.Host()
+
+	a.mu.Lock()
+	if len(a.queue) == 0 {
+		a.mu.Unlock()
+		return
+	}
+	next := a.queue[0]
+	if (host != nil && host.User == next) || a.pendingHost == next {
+		a.mu.Unlock()
+		return
+	}
+	a.pendingHost = next
+	a.pendingID++
+	id := a.pendingID
+	a.mu.Unlock()
+
+	go func() {
+		if err := a.Lobby.SetHost(next); err != nil {
+			a.clearPendingHost(next, id)
+			return
+		}
+		// BanchoBot may never transfer host, then it's given again
+		time.AfterFunc(a.hostTimeout, func() {
+			if a.clearPendingHost(next, id) {
+				a.ensureHost()
+			}
+		})
+	}()
+}
+
+// clearPendingHost forgets the pending host change for the user, id 0 matches any change.
+// Returns false if there is no such pending change.
+func (a *AutoHost) clearPendingHost(u *banchogo.User, id int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pendingHost != u || (id != 0 && a.pendingID != id) {
+		return false
+	}
+	a.pendingHost = nil
+	return true
+}
+
+// indexOf must be called with a.mu locked
+func (a *AutoHost) indexOf(u *banchogo.User) int {
+	for i, q := range a.queue {
+		if q == u {
+			return i
+		}
+	}
+	return -1
+}
+
+// save must be called with a.mu locked. It's called from event handlers, so the queue snapshot
+// is saved by a writer goroutine and only the latest one is written if the store is slow.
+func (a *AutoHost) save() {
+	if a.store == nil {
+		return
+	}
+	a.unsaved = make([]string, len(a.queue))
+	for i, u := range a.queue {
+		a.unsaved[i] = u.Name()
+	}
+	if !a.saving {
+		a.saving = true
+		go a.persist()
+	}
+}
+
+// persist writes snapshots until there are no new ones, a single writer keeps them in order
+func (a *AutoHost) persist() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.unsaved != nil {
+		names := a.unsaved
+		a.unsaved = nil
+		a.mu.Unlock()
+		// Saving is best effort, lobby continues to work with in-memory queue
+		_ = a.store.Save(a.Lobby.Id, names)
+		a.mu.Lock()
+	}
+	a.saving = false
+	a.saved.Broadcast()
+}
+
+// Flush waits until the current queue is saved to the store
+func (a *AutoHost) Flush() {
+	a.mu.Lock()
+	for a.saving {
+		a.saved.Wait()
+	}
+	a.mu.Unlock()
+}
+
+func (a *AutoHost) queueCommand(ctx *router.Context) error {
+	queue := a.Queue()
+	if len(queue) == 0 {
+		return ctx.Reply("Queue is empty")
+	}
+
+	names := make([]string, len(queue))
+	for i, u := range queue {
+		names[i] = strings.ReplaceAll(u.Name(), "_", " ")
+	}
+
+	line := "Queue: " + strings.Join(names, ", ")
+	for len(line) > 400 && len(names) > 1 {
+		names = names[:len(names)-1]
+		line = "Queue: " + strings.Join(names, ", ") + " and " + strconv.Itoa(len(queue)-len(names)) + " more"
+	}
+	return ctx.Reply(line)
+}
+
+func (a *AutoHost) skipCommand(ctx *router.Context) error {
+	if a.Lobby.IsPlaying() {
+		return ctx.Reply("Can't skip the host during a match")
+	}
+
+	host := a.Lobby.Host()
+	if (host != nil && host.User == ctx.User) || a.Lobby.IsReferee(ctx.User) {
+		return a.skip(ctx)
+	}
+	if a.Lobby.Player(ctx.User) == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	a.skipVotes[ctx.User] = struct{}{}
+	votes := len(a.skipVotes)
+	a.mu.Unlock()
+
+	needed := int(math.Ceil(float64(len(a.Lobby.Players())-1) * a.skipRatio))
+	if needed < 1 {
+		needed = 1
+	}
+	if votes >= needed {
+		return a.skip(ctx)
+	}
+	return ctx.Replyf("Skip votes: %d/%d", votes, needed)
+}
+
+func (a *AutoHost) skip(ctx *router.Context) error {
+	if err := a.Rotate(); err != nil {
+		return err
+	}
+	if queue := a.Queue(); len(queue) > 0 {
+		return ctx.Replyf("Host skipped, %s is picking now", strings.ReplaceAll(queue[0].Name(), "_", " "))
+	}
+	return nil
+}
+
+func (a *AutoHost) rulesCommand(ctx *router.Context) error {
+	if len(a.rules) == 0 {
+		return ctx.Reply("This lobby has no special rules")
+	}
+	for _, rule := range a.rules {
+		if err := ctx.Reply(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
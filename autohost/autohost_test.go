@@ -0,0 +1,150 @@
+package autohost
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/banchotest"
+)
+
+func queueNames(a *AutoHost) []string {
+	var names []string
+	for _, u := range a.Queue() {
+		names = append(names, u.Name())
+	}
+	return names
+}
+
+func TestAutoHost_Queue(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "bot"})
+	store := FileStore{Dir: t.TempDir()}
+	a, err := New(client.GetLobby(1), Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range []string{"a", "b", "c"} {
+		a.onPlayerJoined(&banchogo.LobbyPlayer{User: client.GetUser(name), Slot: i + 1})
+	}
+	a.onPlayerJoined(&banchogo.LobbyPlayer{User: client.GetUser("b"), Slot: 5})
+
+	if names := queueNames(a); !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected queue %v", names)
+	}
+
+	a.Rotate()
+	if names := queueNames(a); !reflect.DeepEqual(names, []string{"b", "c", "a"}) {
+		t.Fatalf("unexpected queue after rotation %v", names)
+	}
+
+	a.onPlayerLeft(&banchogo.LobbyPlayer{User: client.GetUser("b")})
+	if names := queueNames(a); !reflect.DeepEqual(names, []string{"c", "a"}) {
+		t.Fatalf("unexpected queue after host left %v", names)
+	}
+
+	a.Flush()
+	saved, err := store.Load(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved, []string{"c", "a"}) {
+		t.Errorf("unexpected saved queue %v", saved)
+	}
+
+	restored, err := New(client.GetLobby(1), Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := queueNames(restored); !reflect.DeepEqual(names, []string{"c", "a"}) {
+		t.Errorf("unexpected restored queue %v", names)
+	}
+}
+
+// slowStore blocks saving until release is closed
+type slowStore struct {
+	release chan struct{}
+	mu      sync.Mutex
+	saved   [][]string
+}
+
+func (s *slowStore) Load(int) ([]string, error) { return nil, nil }
+
+func (s *slowStore) Save(_ int, queue []string) error {
+	<-s.release
+	s.mu.Lock()
+	s.saved = append(s.saved, queue)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestAutoHost_SlowStore(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "bot"})
+	store := &slowStore{release: make(chan struct{})}
+	a, err := New(client.GetLobby(1), Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Event handlers don't wait for the store
+	done := make(chan struct{})
+	go func() {
+		for i, name := range []string{"a", "b", "c"} {
+			a.onPlayerJoined(&banchogo.LobbyPlayer{User: client.GetUser(name), Slot: i + 1})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handlers are blocked by the store")
+	}
+
+	close(store.release)
+	a.Flush()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if last := store.saved[len(store.saved)-1]; !reflect.DeepEqual(last, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected last saved queue %v", last)
+	}
+}
+
+func TestAutoHost_HostTimeout(t *testing.T) {
+	client, conn := banchotest.NewClient(t, banchogo.ClientOptions{})
+
+	a, err := New(client.GetLobby(1), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.hostTimeout = 20 * time.Millisecond
+
+	// BanchoBot never transfers host, so it's given again after the timeout
+	a.onPlayerJoined(&banchogo.LobbyPlayer{User: client.GetUser("a"), Slot: 1})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		hostCommands := 0
+		for _, line := range conn.Sent() {
+			if line == "PRIVMSG #mp_1 :!mp host a" {
+				hostCommands++
+			}
+		}
+		if hostCommands >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("host wasn't given again after the timeout, sent %q", conn.Sent())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A player who left isn't waited for
+	a.onPlayerLeft(&banchogo.LobbyPlayer{User: client.GetUser("a")})
+	a.mu.Lock()
+	pending := a.pendingHost
+	a.mu.Unlock()
+	if pending != nil {
+		t.Errorf("pending host should be cleared when the player leaves, got %s", pending.Name())
+	}
+}
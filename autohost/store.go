@@ -0,0 +1,59 @@
+package autohost
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Store persists host queue, so it survives reconnects and restarts
+type Store interface {
+	// Load returns saved queue of usernames, nil if nothing was saved
+	Load(lobbyId int) ([]string, error)
+	Save(lobbyId int, queue []string) error
+}
+
+// FileStore saves queue of each lobby in a separate JSON file inside Dir
+type FileStore struct {
+	Dir string
+}
+
+type fileStoreData struct {
+	Queue []string `json:"queue"`
+}
+
+func (s FileStore) path(lobbyId int) string {
+	return filepath.Join(s.Dir, "autohost_"+strconv.Itoa(lobbyId)+".json")
+}
+
+func (s FileStore) Load(lobbyId int) ([]string, error) {
+	b, err := os.ReadFile(s.path(lobbyId))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data fileStoreData
+	if err = json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data.Queue, nil
+}
+
+func (s FileStore) Save(lobbyId int, queue []string) error {
+	b, err := json.Marshal(fileStoreData{queue})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash doesn't leave a half written queue
+	tmp := s.path(lobbyId) + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(lobbyId))
+}
@@ -5,13 +5,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
-)
 
-// TODO: make ingame status
+	"github.com/thehowl/go-osuapi"
+)
 
 var (
 	banchoStatsForRegex = regexp.MustCompile(
-		`Stats for \((.+)\)\[https://osu\.ppy\.sh/u/(d+)\](?: is (.+))?`,
+		`Stats for \((.+)\)\[https://osu\.ppy\.sh/u/(\d+)\](?: is (.+))?`,
 	)
 	banchoStatsScoreRegex = regexp.MustCompile(
 		`Score: {4}(.+) \(#(\d+)\)`,
@@ -29,11 +29,19 @@ type BanchoBotStatsResponse struct {
 	Username    string
 	RankedScore int64
 	Rank        int
+	Playcount   int
 	Level       int
 	Accuracy    float64
-	Status      string
-	Online      bool
-	Error       error
+	// Status is the raw status text, e.g. "Idle" or "Multiplaying: [https://osu.ppy.sh/b/75 Artist - Title [Diff]] <Taiko>"
+	Status string
+	// UserStatus is the parsed status, StatusUnknown for offline users
+	UserStatus UserStatus
+	// Beatmap, BeatmapID and Mode are known only if BanchoBot mentions the beatmap in the status
+	Beatmap   string
+	BeatmapID int
+	Mode      osuapi.Mode
+	Online    bool
+	Error     error
 }
 
 type banchoBotStatsCommand struct {
@@ -65,12 +73,17 @@ func newBanchoBotStatsCommand(user *User) (s *banchoBotStatsCommand) {
 	statusHandler := func(m *PrivateMessage) {
 		r := banchoStatsForRegex.FindStringSubmatch(m.Message)
 		if r != nil && s.matchIrcUsername(r[1]) {
-			s.response.Status = r[4]
-			s.response.Online = r[4] != ""
+			s.response.Username = r[1]
+			s.response.UserID, _ = strconv.Atoi(r[2])
+			s.response.Online = r[3] != ""
+			s.parseStatus(r[3])
 
+			// User data is only updated if it was fetched from the API before
 			s.User.mu.Lock()
-			s.User.data.Username = r[1]
-			s.User.data.UserID, _ = strconv.Atoi(r[2])
+			if s.User.data != nil {
+				s.User.data.Username = r[1]
+				s.User.data.UserID = s.response.UserID
+			}
 			s.User.mu.Unlock()
 		}
 	}
@@ -85,8 +98,10 @@ func newBanchoBotStatsCommand(user *User) (s *banchoBotStatsCommand) {
 			s.response.Rank = rank
 
 			s.User.mu.Lock()
-			s.User.data.RankedScore = int64(rankedScore)
-			s.User.data.Rank = rank
+			if s.User.data != nil {
+				s.User.data.RankedScore = int64(rankedScore)
+				s.User.data.Rank = rank
+			}
 			s.User.mu.Unlock()
 		}
 	}
@@ -94,8 +109,14 @@ func newBanchoBotStatsCommand(user *User) (s *banchoBotStatsCommand) {
 	playsHandler := func(m *PrivateMessage) {
 		r := banchoStatsPlaysRegex.FindStringSubmatch(m.Message)
 		if r != nil {
-			s.User.data.Playcount, _ = strconv.Atoi(r[1])
+			s.response.Playcount, _ = strconv.Atoi(r[1])
 			s.response.Level, _ = strconv.Atoi(r[2])
+
+			s.User.mu.Lock()
+			if s.User.data != nil {
+				s.User.data.Playcount = s.response.Playcount
+			}
+			s.User.mu.Unlock()
 		}
 	}
 
@@ -113,6 +134,46 @@ func newBanchoBotStatsCommand(user *User) (s *banchoBotStatsCommand) {
 	return
 }
 
+// parseStatus parses status text like "Playing" or "Multiplaying: [https://osu.ppy.sh/b/75 Artist - Title [Diff]] <Taiko>"
+func (s *banchoBotStatsCommand) parseStatus(text string) {
+	// Statuses without details end with a colon, e.g. "Idle:"
+	s.response.Status = strings.TrimSuffix(text, ":")
+	if text == "" {
+		return
+	}
+
+	name, rest, _ := strings.Cut(text, " ")
+	name = strings.TrimSuffix(name, ":")
+	s.response.UserStatus = parseUserStatus(name)
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return
+	}
+	for _, flag := range strings.Fields(rest) {
+		if strings.HasPrefix(flag, "<") && strings.HasSuffix(flag, ">") {
+			if mode, ok := nowPlayingModes[strings.ToLower(strings.Trim(flag, "<>"))]; ok {
+				s.response.Mode = mode
+				rest = strings.TrimSpace(strings.Replace(rest, flag, "", 1))
+			}
+		}
+	}
+
+	s.response.Beatmap = rest
+	for _, l := range ParseLinks(rest) {
+		if ref, ok := l.Ref.(BeatmapRef); ok {
+			s.response.BeatmapID = ref.ID
+			if l.Label != "" {
+				s.response.Beatmap = l.Label
+			}
+			if mode, ok := ref.GameMode(); ok {
+				s.response.Mode = mode
+			}
+			break
+		}
+	}
+}
+
 func (s *banchoBotStatsCommand) Send() chan BanchoBotStatsResponse {
 	s.registerHandlers()
 	s.User.client.GetUser("BanchoBot").SendMessage("!stats " + s.User.Name())
@@ -140,5 +201,5 @@ func (s *banchoBotStatsCommand) removeHandlers() {
 }
 
 func (s *banchoBotStatsCommand) matchIrcUsername(username string) bool {
-	return strings.ReplaceAll(username, " ", "_") == strings.ToLower(s.User.Name())
+	return strings.EqualFold(strings.ReplaceAll(username, " ", "_"), s.User.Name())
 }
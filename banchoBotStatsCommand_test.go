@@ -0,0 +1,60 @@
+package banchogo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestUser_Stats(t *testing.T) {
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+
+	tests := []struct {
+		status string
+		check  func(r BanchoBotStatsResponse) bool
+	}{
+		{" is Idle:", func(r BanchoBotStatsResponse) bool {
+			return r.Online && r.UserStatus == StatusIdle && r.Status == "Idle"
+		}},
+		{"", func(r BanchoBotStatsResponse) bool {
+			return !r.Online && r.UserStatus == StatusUnknown
+		}},
+		{" is Multiplaying: [https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE [Normal]] <Taiko>", func(r BanchoBotStatsResponse) bool {
+			return r.UserStatus == StatusMultiplaying && r.BeatmapID == 75 && r.Mode == osuapi.ModeTaiko &&
+				r.Beatmap == "Kenji Ninuma - DISCO PRINCE [Normal]" &&
+				r.Status == "Multiplaying: [https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE [Normal]] <Taiko>"
+		}},
+	}
+
+	for _, tt := range tests {
+		// The user was never fetched from the API, its data must stay untouched
+		resp := b.GetUser("Some_Player").Stats()
+		feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG bot :Stats for (Some Player)[https://osu.ppy.sh/u/123]"+tt.status)
+		feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG bot :Score:    1,234,567 (#4321)")
+		feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG bot :Plays:    150 (lv42)")
+		feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG bot :Accuracy: 98.76%")
+
+		select {
+		case r := <-resp:
+			if r.Error != nil || r.UserID != 123 || r.Username != "Some Player" || r.Rank != 4321 ||
+				r.RankedScore != 1234567 || r.Playcount != 150 || r.Level != 42 || r.Accuracy != 98.76 || !tt.check(r) {
+				t.Errorf("unexpected response for status %q: %+v", tt.status, r)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no stats response")
+		}
+	}
+
+	if d := b.GetUser("Some_Player").Data(); d.UserID != 0 {
+		t.Errorf("user data shouldn't be created by stats, got %+v", d)
+	}
+}
+
+func TestParseUserStatus(t *testing.T) {
+	for s, want := range map[string]UserStatus{"Afk": StatusAfk, "osu!direct": StatusOsuDirect, "lobby": StatusLobby, "Dancing": StatusUnknown} {
+		if got := parseUserStatus(s); got != want {
+			t.Errorf("parseUserStatus(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package banchogo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robloxxa/banchogo/apiv2"
+	"github.com/thehowl/go-osuapi"
+)
+
+var (
+	ErrApiNotConfigured = errors.New("neither osu! api key nor api v2 client is configured")
+	ErrBeatmapNotFound  = errors.New("beatmap not found")
+)
+
+// Beatmap is beatmap metadata resolved through the osu! API
+type Beatmap struct {
+	ID    int
+	SetID int
+
+	Artist  string
+	Title   string
+	Version string
+	Creator string
+
+	Mode       osuapi.Mode
+	Status     osuapi.ApprovedStatus
+	StarRating float64
+
+	// Length is the total length, DrainLength excludes breaks and the intro
+	Length      time.Duration
+	DrainLength time.Duration
+
+	BPM      float64
+	AR       float64
+	OD       float64
+	CS       float64
+	HP       float64
+	MaxCombo int
+}
+
+// FullTitle returns a title like "Artist - Title [Version]"
+func (b *Beatmap) FullTitle() string {
+	return b.Artist + " - " + b.Title + " [" + b.Version + "]"
+}
+
+var apiv2Statuses = map[string]osuapi.ApprovedStatus{
+	"graveyard": osuapi.StatusGraveyard,
+	"wip":       osuapi.StatusWIP,
+	"pending":   osuapi.StatusPending,
+	"ranked":    osuapi.StatusRanked,
+	"approved":  osuapi.StatusApproved,
+	"qualified": osuapi.StatusQualified,
+	"loved":     osuapi.StatusLoved,
+}
+
+// FetchBeatmap resolves beatmap metadata with API v2 if it's configured, otherwise with the legacy API.
+// Results are cached in Client.Cache.
+func (b *Client) FetchBeatmap(id int) (*Beatmap, error) {
+	if b.ApiV2 == nil && b.Api == (osuapi.Client{}) {
+		return nil, ErrApiNotConfigured
+	}
+
+	beatmap, err := cachedFetch(b.Cache, "beatmap:"+strconv.Itoa(id), func() (Beatmap, error) {
+		if b.ApiV2 != nil {
+			m, err := b.ApiV2.GetBeatmap(context.Background(), id)
+			if err != nil {
+				return Beatmap{}, err
+			}
+			return beatmapFromV2(m), nil
+		}
+
+		maps, err := b.Api.GetBeatmaps(osuapi.GetBeatmapsOpts{BeatmapID: id})
+		if err != nil {
+			return Beatmap{}, err
+		}
+		if len(maps) == 0 {
+			return Beatmap{}, ErrBeatmapNotFound
+		}
+		return beatmapFromV1(maps[0]), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &beatmap, nil
+}
+
+func beatmapFromV1(m osuapi.Beatmap) Beatmap {
+	return Beatmap{
+		ID:          m.BeatmapID,
+		SetID:       m.BeatmapSetID,
+		Artist:      m.Artist,
+		Title:       m.Title,
+		Version:     m.DiffName,
+		Creator:     m.Creator,
+		Mode:        m.Mode,
+		Status:      m.Approved,
+		StarRating:  m.DifficultyRating,
+		Length:      time.Duration(m.TotalLength) * time.Second,
+		DrainLength: time.Duration(m.HitLength) * time.Second,
+		BPM:         m.BPM,
+		AR:          m.ApproachRate,
+		OD:          m.OverallDifficulty,
+		CS:          m.CircleSize,
+		HP:          m.HPDrain,
+		MaxCombo:    m.MaxCombo,
+	}
+}
+
+func beatmapFromV2(m *apiv2.Beatmap) Beatmap {
+	beatmap := Beatmap{
+		ID:          m.ID,
+		SetID:       m.BeatmapsetID,
+		Version:     m.Version,
+		Status:      apiv2Statuses[strings.ToLower(m.Status)],
+		StarRating:  m.DifficultyRating,
+		Length:      time.Duration(m.TotalLength) * time.Second,
+		DrainLength: time.Duration(m.HitLength) * time.Second,
+		BPM:         m.BPM,
+		AR:          m.AR,
+		OD:          m.OD,
+		CS:          m.CS,
+		HP:          m.HP,
+		MaxCombo:    m.MaxCombo,
+	}
+	for i, mode := range apiv2.Modes {
+		if mode == m.Mode {
+			beatmap.Mode = osuapi.Mode(i)
+		}
+	}
+	if s := m.Beatmapset; s != nil {
+		beatmap.Artist = s.Artist
+		beatmap.Title = s.Title
+		beatmap.Creator = s.Creator
+	}
+	return beatmap
+}
+
+// ResolveBeatmap fetches metadata of the current lobby beatmap, it's done automatically when the beatmap changes
+func (l *Lobby) ResolveBeatmap() (*Beatmap, error) {
+	id := l.BeatmapID()
+	if id == 0 {
+		return nil, ErrBeatmapNotFound
+	}
+
+	beatmap, err := l.Client.FetchBeatmap(id)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	if l.beatmapId != id {
+		l.mu.Unlock()
+		return beatmap, nil
+	}
+	info := *beatmap
+	l.beatmapInfo = &info
+	l.mu.Unlock()
+
+	resolved := *beatmap
+	l.ev.Emit("BeatmapResolved", &resolved)
+	return beatmap, nil
+}
+
+// Beatmap returns metadata of the current lobby beatmap, nil until it is resolved.
+// Metadata is resolved only if the client has an osu! API key or API v2 client.
+func (l *Lobby) Beatmap() *Beatmap {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.beatmapInfo == nil || l.beatmapInfo.ID != l.beatmapId {
+		return nil
+	}
+	beatmap := *l.beatmapInfo
+	return &beatmap
+}
+
+// resolveBeatmapAsync resolves the beatmap in background if the client has access to the API
+func (l *Lobby) resolveBeatmapAsync() {
+	if l.Client.ApiV2 == nil && l.Client.Api == (osuapi.Client{}) {
+		return
+	}
+	go l.ResolveBeatmap()
+}
@@ -0,0 +1,58 @@
+package banchogo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo/apiv2"
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestLobby_BeatmapResolved(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.Write([]byte(`{"access_token":"token","expires_in":86400}`))
+		case "/api/v2/beatmaps/75":
+			w.Write([]byte(`{"id":75,"beatmapset_id":1,"version":"Normal","mode":"osu","status":"ranked","difficulty_rating":2.55,
+				"total_length":142,"hit_length":109,"bpm":119.999,"ar":6,"accuracy":6,"cs":4,"drain":6,"max_combo":314,
+				"beatmapset":{"artist":"Kenji Ninuma","title":"DISCO PRINCE","creator":"peppy"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	b := NewBanchoClient(ClientOptions{Username: "bot", ApiV2: apiv2.New(apiv2.Options{BaseURL: server.URL})})
+	l := b.GetLobby(123)
+
+	resolved := make(chan *Beatmap, 1)
+	l.OnceBeatmapResolved(func(m *Beatmap) { resolved <- m })
+
+	if l.Beatmap() != nil {
+		t.Fatal("beatmap shouldn't be resolved yet")
+	}
+	feedLobby(l, "Beatmap changed to: Kenji Ninuma - DISCO PRINCE [Normal] (https://osu.ppy.sh/b/75)")
+
+	select {
+	case m := <-resolved:
+		if m.FullTitle() != "Kenji Ninuma - DISCO PRINCE [Normal]" || m.Status != osuapi.StatusRanked ||
+			m.Length != 142*time.Second || m.AR != 6 || m.StarRating != 2.55 {
+			t.Errorf("unexpected beatmap %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("beatmap wasn't resolved")
+	}
+
+	if m := l.Beatmap(); m == nil || m.ID != 75 {
+		t.Errorf("expected resolved beatmap, got %+v", m)
+	}
+
+	// Metadata of the previous beatmap isn't returned after the map changes
+	feedLobby(l, "Changed beatmap to https://osu.ppy.sh/b/1 Unknown map")
+	if l.Beatmap() != nil {
+		t.Error("stale beatmap metadata returned")
+	}
+}
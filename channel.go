@@ -3,6 +3,7 @@ package banchogo
 import (
 	"github.com/puzpuzpuz/xsync/v2"
 	"runtime"
+	"sync"
 	"time"
 )
 
@@ -15,7 +16,10 @@ type Channel struct {
 	Joined      bool
 	Members     *xsync.MapOf[string, *ChannelMember]
 
-	handlerRemovers [3]func()
+	handlerRemovers [4]func()
+
+	historyOnce sync.Once
+	history     *History
 
 	joinSignal chan error
 	partSignal chan error
@@ -41,7 +45,7 @@ func (c *Channel) SendMessage(message string) error {
 }
 
 func (c *Channel) SendAction(message string) error {
-	return newOutgoingBanchoMessage(c.client, c, "\x01ACTION "+message+"\x01").Send()
+	return newOutgoingBanchoMessage(c.client, c, EncodeCTCP("ACTION", message)).Send()
 }
 
 func (c *Channel) Type() string {
@@ -52,9 +56,9 @@ func (c *Channel) on(name string, handler interface{}, once bool) func() {
 
 	if c.ev == nil {
 
-		c.ev = &EventEmitter{}
+		c.ev = &EventEmitter{observe: c.client.observeHandler}
 
-		c.handlerRemovers = [3]func(){
+		c.handlerRemovers = [4]func(){
 			c.client.OnChannelMessage(func(m *ChannelMessage) {
 				if m.Channel != c {
 					return
@@ -66,19 +70,26 @@ func (c *Channel) on(name string, handler interface{}, once bool) func() {
 				if m.Channel != c {
 					return
 				}
-				c.ev.Emit("Join")
+				c.ev.Emit("Join", m)
 			}),
 
 			c.client.OnPart(func(m *ChannelMember) {
 				if m.Channel != c {
 					return
 				}
-				c.ev.Emit("Part")
+				c.ev.Emit("Part", m)
+			}),
+
+			c.client.OnModeChange(func(m *ChannelMember, old, new ChannelMemberMode) {
+				if m.Channel != c {
+					return
+				}
+				c.ev.Emit("ModeChange", m, old, new)
 			})}
 
 		// TODO: Figure out the proper way to clear events when object is gced
 		// Note: SetFinalizer prevent object to be freed when gc tries to free it for the first time
-		runtime.SetFinalizer(c, func() {
+		runtime.SetFinalizer(c, func(c *Channel) {
 			for _, f := range c.handlerRemovers {
 				f()
 			}
@@ -140,3 +151,23 @@ func (c *Channel) OnPart(handler func(*ChannelMember)) func() {
 func (c *Channel) OncePart(handler func(*ChannelMember)) func() {
 	return c.on("Part", handler, true)
 }
+
+func (c *Channel) OnModeChange(handler func(member *ChannelMember, old, new ChannelMemberMode)) func() {
+	return c.on("ModeChange", handler, false)
+}
+
+func (c *Channel) OnceModeChange(handler func(member *ChannelMember, old, new ChannelMemberMode)) func() {
+	return c.on("ModeChange", handler, true)
+}
+
+// Moderators returns members with operator mode
+func (c *Channel) Moderators() []*ChannelMember {
+	var mods []*ChannelMember
+	c.Members.Range(func(_ string, m *ChannelMember) bool {
+		if m.IsModerator() {
+			mods = append(mods, m)
+		}
+		return true
+	})
+	return mods
+}
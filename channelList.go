@@ -0,0 +1,112 @@
+package banchogo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelInfo is a public channel returned by ListChannels
+type ChannelInfo struct {
+	Name      string
+	UserCount int
+	Topic     string
+}
+
+// channelListRequest collects 322 replies, concurrent ListChannels calls share a single LIST
+type channelListRequest struct {
+	channels []ChannelInfo
+	err      error
+	done     chan struct{}
+	timer    *time.Timer
+}
+
+// ListChannels returns public channels with their topics and user counts.
+// Topics of known Channel objects are updated as well.
+func (b *Client) ListChannels(ctx context.Context) ([]ChannelInfo, error) {
+	b.listMu.Lock()
+	req := b.listRequest
+	if req == nil {
+		req = &channelListRequest{done: make(chan struct{})}
+		b.listRequest = req
+		req.timer = time.AfterFunc(30*time.Second, func() {
+			b.finishChannelList(req, ErrMessageTimeout)
+		})
+		b.listMu.Unlock()
+
+		if err := b.Send("LIST"); err != nil {
+			b.finishChannelList(req, err)
+		}
+	} else {
+		b.listMu.Unlock()
+	}
+
+	select {
+	case <-req.done:
+		if req.err != nil {
+			return nil, req.err
+		}
+		return append([]ChannelInfo(nil), req.channels...), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// finishChannelList completes the request, does nothing if it's already completed
+func (b *Client) finishChannelList(req *channelListRequest, err error) {
+	b.listMu.Lock()
+	defer b.listMu.Unlock()
+	if b.listRequest != req {
+		return
+	}
+	b.listRequest = nil
+
+	req.timer.Stop()
+	req.err = err
+	close(req.done)
+}
+
+// :cho.ppy.sh 321 bot Channel :Users Name
+func handleListStartCommand(b *Client, _ []string) {
+	b.listMu.Lock()
+	if b.listRequest != nil {
+		b.listRequest.channels = nil
+	}
+	b.listMu.Unlock()
+}
+
+// :cho.ppy.sh 322 bot #osu 1234 :General discussion
+func handleListCommand(b *Client, splits []string) {
+	if len(splits) < 5 {
+		return
+	}
+
+	info := ChannelInfo{Name: splits[3]}
+	info.UserCount, _ = strconv.Atoi(splits[4])
+	info.Topic = strings.TrimPrefix(strings.Join(splits[5:], " "), ":")
+
+	// Only known channels are updated, LIST shouldn't create objects for every public channel
+	b.Channels.Compute(info.Name, func(c *Channel, loaded bool) (*Channel, bool) {
+		if loaded {
+			c.Topic = info.Topic
+		}
+		return c, !loaded
+	})
+
+	b.listMu.Lock()
+	if b.listRequest != nil {
+		b.listRequest.channels = append(b.listRequest.channels, info)
+	}
+	b.listMu.Unlock()
+}
+
+// :cho.ppy.sh 323 bot :End of /LIST
+func handleListEndCommand(b *Client, _ []string) {
+	b.listMu.Lock()
+	req := b.listRequest
+	b.listMu.Unlock()
+	if req != nil {
+		b.finishChannelList(req, nil)
+	}
+}
@@ -0,0 +1,59 @@
+package banchogo
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_ListChannels(t *testing.T) {
+	b, conn := newTestClient(t,
+		"100\t:cho.ppy.sh 321 bot Channel :Users Name",
+		":cho.ppy.sh 322 bot #osu 1234 :General discussion.",
+		":cho.ppy.sh 322 bot #lobby 56 :",
+		":cho.ppy.sh 323 bot :End of /LIST")
+
+	osu, _ := b.GetChannel("#osu")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Concurrent callers share a single LIST request
+	var wg sync.WaitGroup
+	results := make([][]ChannelInfo, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if results[i], err = b.ListChannels(ctx); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	want := []ChannelInfo{{"#osu", 1234, "General discussion."}, {"#lobby", 56, ""}}
+	for _, r := range results {
+		if !reflect.DeepEqual(r, want) {
+			t.Errorf("unexpected channels %+v", r)
+		}
+	}
+	if osu.Topic != "General discussion." {
+		t.Errorf("topic of a known channel wasn't updated, got %q", osu.Topic)
+	}
+	if _, ok := b.Channels.Load("#lobby"); ok {
+		t.Error("unknown channels shouldn't be created")
+	}
+	if sent := conn.Sent()[3:]; len(sent) != 1 || sent[0] != "LIST" {
+		t.Errorf("expected a single LIST, got %q", sent)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.ListChannels(cancelled); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
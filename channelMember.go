@@ -6,7 +6,7 @@ type ChannelMemberMode string
 
 const (
 	IRCUser      ChannelMemberMode = "v"
-	IRCModerator                   = "o"
+	IRCModerator ChannelMemberMode = "o"
 )
 
 func (c ChannelMemberMode) ToSymbol() string {
@@ -35,6 +35,7 @@ func newChannelMember(b *Client, channel *Channel, username string) (c *ChannelM
 		username = username[1:]
 	} else if strings.Index(username, "+") == 0 {
 		c.Mode = IRCUser
+		username = username[1:]
 	} else {
 		c.Mode = ""
 	}
@@ -42,3 +43,8 @@ func newChannelMember(b *Client, channel *Channel, username string) (c *ChannelM
 	return
 
 }
+
+// IsModerator reports whether the member has channel operator mode, e.g. GMT members in #osu
+func (c *ChannelMember) IsModerator() bool {
+	return c.Mode == IRCModerator
+}
@@ -0,0 +1,45 @@
+package banchogo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannel_ModeChange(t *testing.T) {
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+
+	channel, _ := b.GetChannel("#osu")
+	var changes []string
+	b.OnModeChange(func(m *ChannelMember, old, new ChannelMemberMode) {
+		changes = append(changes, m.User.Name()+" "+string(old)+">"+string(new))
+	})
+	channelChanges := 0
+	channel.OnModeChange(func(*ChannelMember, ChannelMemberMode, ChannelMemberMode) { channelChanges++ })
+
+	feedLine(b, ":cho.ppy.sh 353 bot = #osu :@peppy +voiced regular")
+	if m, _ := channel.Members.Load("voiced"); m == nil || m.Mode != IRCUser {
+		t.Fatalf("voiced member wasn't parsed from names, got %+v", m)
+	}
+
+	feedLine(b, ":BanchoBot!cho@ppy.sh MODE #osu +o regular")
+	feedLine(b, ":BanchoBot!cho@ppy.sh MODE #osu +v peppy")
+	feedLine(b, ":BanchoBot!cho@ppy.sh MODE #osu -o+o peppy newcomer")
+	feedLine(b, ":BanchoBot!cho@ppy.sh MODE #osu -v voiced")
+	feedLine(b, ":BanchoBot!cho@ppy.sh MODE #osu -o stranger")
+
+	want := []string{"regular >o", "peppy o>", "newcomer >o", "voiced v>"}
+	if strings.Join(changes, ",") != strings.Join(want, ",") || channelChanges != 4 {
+		t.Errorf("unexpected mode changes %q, %d in channel", changes, channelChanges)
+	}
+	if _, ok := channel.Members.Load("stranger"); ok {
+		t.Error("removing a mode shouldn't add an unknown member")
+	}
+
+	mods := map[string]bool{}
+	for _, m := range channel.Moderators() {
+		mods[m.User.Name()] = true
+	}
+	if len(mods) != 2 || !mods["regular"] || !mods["newcomer"] {
+		t.Errorf("unexpected moderators %v", mods)
+	}
+}
@@ -0,0 +1,222 @@
+package banchogo
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+const osuBaseURL = "https://osu.ppy.sh"
+
+// rulesetNames are game mode names used in osu! links, indexed by osuapi.Mode
+var rulesetNames = [...]string{"osu", "taiko", "fruits", "mania"}
+
+var (
+	// Link formats rendered by osu! chat:
+	// [https://url label], [label](https://url), (label)[https://url] and bare urls.
+	// Labels can contain one level of balanced brackets, e.g. a difficulty name.
+	chatLinkRegex = regexp.MustCompile(
+		`\[(https?://[^\s\]]+) ((?:[^\[\]]|\[[^\[\]]*\])+)\]` +
+			`|\[((?:[^\[\]]|\[[^\[\]]*\])+)\]\((https?://[^\s)]+)\)` +
+			`|\(([^()]+)\)\[(https?://[^\s\]]+)\]` +
+			`|(https?://[^\s\[\]<>"]+)`,
+	)
+
+	linkBeatmapRegex    = regexp.MustCompile(`^/(?:b|beatmaps)/(\d+)/?$`)
+	linkBeatmapsetRegex = regexp.MustCompile(`^/(?:s|beatmapsets)/(\d+)/?$`)
+	linkSetFragRegex    = regexp.MustCompile(`^(osu|taiko|fruits|mania)?/(\d+)$`)
+	linkUserRegex       = regexp.MustCompile(`^/(?:u|users)/([^/]+)(?:/(osu|taiko|fruits|mania))?/?$`)
+	linkMatchRegex      = regexp.MustCompile(`^/(?:mp|community/matches)/(\d+)/?$`)
+
+	linkLabelReplacer = strings.NewReplacer("[", "［", "]", "］", "\n", " ", "\r", "")
+)
+
+// LinkRef is something a chat link points to: BeatmapRef, BeatmapsetRef, UserRef, MatchRef or URLRef
+type LinkRef interface {
+	URL() string
+}
+
+// BeatmapRef is a link to a beatmap difficulty
+type BeatmapRef struct {
+	ID int
+	// SetID is known only from beatmapset links like /beatmapsets/1#osu/75
+	SetID int
+	// Mode is a ruleset name from the link ("osu", "taiko", "fruits", "mania"), empty if unknown
+	Mode string
+}
+
+func (r BeatmapRef) URL() string {
+	return BeatmapURL(r.ID)
+}
+
+// GameMode returns the ruleset from the link as osuapi.Mode, ok is false if the link has no ruleset
+func (r BeatmapRef) GameMode() (mode osuapi.Mode, ok bool) {
+	for i, name := range rulesetNames {
+		if name == r.Mode {
+			return osuapi.Mode(i), true
+		}
+	}
+	return osuapi.ModeOsu, false
+}
+
+// BeatmapsetRef is a link to a beatmapset without a selected difficulty
+type BeatmapsetRef struct {
+	ID int
+}
+
+func (r BeatmapsetRef) URL() string {
+	return BeatmapsetURL(r.ID)
+}
+
+// UserRef is a link to a profile, either by ID or by username
+type UserRef struct {
+	ID   int
+	Name string
+}
+
+func (r UserRef) URL() string {
+	if r.ID == 0 {
+		return osuBaseURL + "/u/" + url.PathEscape(r.Name)
+	}
+	return UserURL(r.ID)
+}
+
+// MatchRef is a link to a multiplayer match history
+type MatchRef struct {
+	ID int
+}
+
+func (r MatchRef) URL() string {
+	return MatchURL(r.ID)
+}
+
+// URLRef is any other link
+type URLRef string
+
+func (r URLRef) URL() string {
+	return string(r)
+}
+
+// ChatLink is a link found in a chat message
+type ChatLink struct {
+	URL string
+	// Label is empty for bare urls
+	Label string
+	Ref   LinkRef
+}
+
+func BeatmapURL(id int) string {
+	return osuBaseURL + "/b/" + strconv.Itoa(id)
+}
+
+func BeatmapsetURL(id int) string {
+	return osuBaseURL + "/beatmapsets/" + strconv.Itoa(id)
+}
+
+func UserURL(id int) string {
+	return osuBaseURL + "/u/" + strconv.Itoa(id)
+}
+
+func MatchURL(id int) string {
+	return osuBaseURL + "/mp/" + strconv.Itoa(id)
+}
+
+// EscapeLinkLabel makes text safe to use as a link label. osu! chat has no escape sequences,
+// so square brackets are replaced with full width ones and line breaks with spaces.
+func EscapeLinkLabel(label string) string {
+	return linkLabelReplacer.Replace(label)
+}
+
+// Link formats a labeled link, e.g. "[https://osu.ppy.sh/b/75 DISCO PRINCE]". Returns the url as is if label is empty.
+func Link(url, label string) string {
+	url = strings.ReplaceAll(url, " ", "%20")
+	label = strings.TrimSpace(EscapeLinkLabel(label))
+	if label == "" {
+		return url
+	}
+	return "[" + url + " " + label + "]"
+}
+
+func BeatmapLink(id int, label string) string {
+	return Link(BeatmapURL(id), label)
+}
+
+func BeatmapsetLink(id int, label string) string {
+	return Link(BeatmapsetURL(id), label)
+}
+
+func UserLink(id int, label string) string {
+	return Link(UserURL(id), label)
+}
+
+func MatchLink(id int, label string) string {
+	return Link(MatchURL(id), label)
+}
+
+// ParseLinks returns all links in a chat message in order of appearance
+func ParseLinks(content string) []ChatLink {
+	var links []ChatLink
+	for _, m := range chatLinkRegex.FindAllStringSubmatch(content, -1) {
+		var l ChatLink
+		switch {
+		case m[1] != "":
+			l.URL, l.Label = m[1], m[2]
+		case m[4] != "":
+			l.URL, l.Label = m[4], m[3]
+		case m[6] != "":
+			l.URL, l.Label = m[6], m[5]
+		default:
+			l.URL = strings.TrimRight(m[7], ".,!?:;")
+			if strings.HasSuffix(l.URL, ")") && !strings.Contains(l.URL, "(") {
+				l.URL = strings.TrimSuffix(l.URL, ")")
+			}
+		}
+		l.Ref = ParseLinkRef(l.URL)
+		links = append(links, l)
+	}
+	return links
+}
+
+// ParseLinkRef recognizes osu! beatmap, beatmapset, user and match urls, other urls are returned as URLRef
+func ParseLinkRef(rawURL string) LinkRef {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(strings.TrimPrefix(u.Hostname(), "www."), "osu.ppy.sh") {
+		return URLRef(rawURL)
+	}
+
+	if m := linkBeatmapRegex.FindStringSubmatch(u.Path); m != nil {
+		ref := BeatmapRef{}
+		ref.ID, _ = strconv.Atoi(m[1])
+		if mode, err := strconv.Atoi(u.Query().Get("m")); err == nil && mode >= 0 && mode < len(rulesetNames) {
+			ref.Mode = rulesetNames[mode]
+		}
+		return ref
+	}
+	if m := linkBeatmapsetRegex.FindStringSubmatch(u.Path); m != nil {
+		setID, _ := strconv.Atoi(m[1])
+		if f := linkSetFragRegex.FindStringSubmatch(u.Fragment); f != nil {
+			id, _ := strconv.Atoi(f[2])
+			return BeatmapRef{ID: id, SetID: setID, Mode: f[1]}
+		}
+		return BeatmapsetRef{ID: setID}
+	}
+	if m := linkUserRegex.FindStringSubmatch(u.Path); m != nil {
+		if id, err := strconv.Atoi(m[1]); err == nil {
+			return UserRef{ID: id}
+		}
+		return UserRef{Name: m[1]}
+	}
+	if m := linkMatchRegex.FindStringSubmatch(u.Path); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		return MatchRef{ID: id}
+	}
+	return URLRef(rawURL)
+}
+
+// Links returns all links in the message
+func (b *message) Links() []ChatLink {
+	return ParseLinks(b.Content())
+}
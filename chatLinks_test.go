@@ -0,0 +1,50 @@
+package banchogo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{BeatmapLink(75, "Kenji Ninuma - DISCO PRINCE [Normal]"), "[https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE ［Normal］]"},
+		{UserLink(2, "peppy"), "[https://osu.ppy.sh/u/2 peppy]"},
+		{MatchLink(123, ""), "https://osu.ppy.sh/mp/123"},
+		{Link("https://example.com/a b", "multi\nline"), "[https://example.com/a%20b multi line]"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	content := "pick [https://osu.ppy.sh/beatmapsets/1#taiko/75 DISCO PRINCE [Normal]] or " +
+		"[set](https://osu.ppy.sh/s/2), ask (peppy)[https://osu.ppy.sh/users/2] and see " +
+		"https://osu.ppy.sh/mp/123. Also https://osu.ppy.sh/b/5?m=3 https://osu.ppy.sh/u/Some%20Player (https://example.com/x)"
+
+	want := []ChatLink{
+		{"https://osu.ppy.sh/beatmapsets/1#taiko/75", "DISCO PRINCE [Normal]", BeatmapRef{ID: 75, SetID: 1, Mode: "taiko"}},
+		{"https://osu.ppy.sh/s/2", "set", BeatmapsetRef{ID: 2}},
+		{"https://osu.ppy.sh/users/2", "peppy", UserRef{ID: 2}},
+		{"https://osu.ppy.sh/mp/123", "", MatchRef{ID: 123}},
+		{"https://osu.ppy.sh/b/5?m=3", "", BeatmapRef{ID: 5, Mode: "mania"}},
+		{"https://osu.ppy.sh/u/Some%20Player", "", UserRef{Name: "Some Player"}},
+		{"https://example.com/x", "", URLRef("https://example.com/x")},
+	}
+	if got := ParseLinks(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected links:\n%+v\nwant\n%+v", got, want)
+	}
+
+	if mode, ok := (BeatmapRef{ID: 75, Mode: "taiko"}).GameMode(); !ok || mode != osuapi.ModeTaiko {
+		t.Errorf("expected taiko mode, got %v", mode)
+	}
+	if u := (UserRef{Name: "Some Player"}).URL(); u != "https://osu.ppy.sh/u/Some%20Player" {
+		t.Errorf("unexpected user url %q", u)
+	}
+}
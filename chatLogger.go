@@ -0,0 +1,411 @@
+package banchogo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFormat is a chat log file format, formats can be combined
+type LogFormat int
+
+const (
+	// LogText is a human-readable IRC log, written to .log files
+	LogText LogFormat = 1 << iota
+	// LogJSONL is a ChatLogEntry per line, written to .jsonl files
+	LogJSONL
+)
+
+var logExtensions = map[LogFormat]string{
+	LogText:  ".log",
+	LogJSONL: ".jsonl",
+}
+
+type ChatLogEntryType string
+
+const (
+	LogMessage ChatLogEntryType = "message"
+	LogAction  ChatLogEntryType = "action"
+	LogJoin    ChatLogEntryType = "join"
+	LogPart    ChatLogEntryType = "part"
+	LogQuit    ChatLogEntryType = "quit"
+	LogTopic   ChatLogEntryType = "topic"
+)
+
+// ChatLogEntry is a single logged event
+type ChatLogEntry struct {
+	Time time.Time        `json:"time"`
+	Type ChatLogEntryType `json:"type"`
+	// Target is a channel name or a username of the other side of a private conversation
+	Target  string `json:"target"`
+	User    string `json:"user,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+func (e ChatLogEntry) text() string {
+	ts := e.Time.Format("15:04:05")
+	switch e.Type {
+	case LogAction:
+		return fmt.Sprintf("[%s] * %s %s", ts, e.User, e.Content)
+	case LogJoin:
+		return fmt.Sprintf("[%s] --> %s has joined %s", ts, e.User, e.Target)
+	case LogPart:
+		return fmt.Sprintf("[%s] <-- %s has left %s", ts, e.User, e.Target)
+	case LogQuit:
+		return fmt.Sprintf("[%s] <-- %s has quit", ts, e.User)
+	case LogTopic:
+		return fmt.Sprintf("[%s] -- Topic for %s is: %s", ts, e.Target, e.Content)
+	default:
+		return fmt.Sprintf("[%s] <%s> %s", ts, e.User, e.Content)
+	}
+}
+
+type ChatLoggerOptions struct {
+	// Dir is a root directory, every channel and private conversation gets its own subdirectory with a file per day
+	Dir string
+	// Formats are written formats, LogText|LogJSONL by default
+	Formats LogFormat
+	// Location is used for timestamps and day boundaries, UTC by default
+	Location *time.Location
+
+	// MaxAge removes files of days that ended longer than MaxAge ago, 0 keeps them forever
+	MaxAge time.Duration
+	// MaxFiles keeps only the newest files of each channel and format, 0 means no limit
+	MaxFiles int
+
+	// Filter decides whether a channel or a private conversation is logged, everything is logged by default
+	Filter func(target string) bool
+}
+
+const (
+	// maxOpenChatLogFiles limits file handles kept open by ChatLogger
+	maxOpenChatLogFiles = 64
+	// chatLogIdleTimeout closes files of conversations which went quiet
+	chatLogIdleTimeout = 5 * time.Minute
+)
+
+var ErrChatLoggerClosed = errors.New("chat logger is closed")
+
+// ChatLogger writes client chat to rotating per-channel and per-day files.
+// It logs private, channel and lobby messages, joins, parts, quits and topics.
+// Entries are written by a background goroutine, so logging never waits for the disk.
+type ChatLogger struct {
+	opt ChatLoggerOptions
+	now func() time.Time
+
+	mu     sync.Mutex
+	queue  []chatLogRecord
+	wake   chan struct{}
+	done   chan struct{}
+	closed bool
+	err    error
+
+	// files are used only by the writer goroutine
+	files map[string]*chatLogFile
+
+	handlerRemovers []func()
+}
+
+// chatLogRecord is a line to write, or a flush request if flushed isn't nil
+type chatLogRecord struct {
+	target  string
+	ext     string
+	time    time.Time
+	data    []byte
+	flushed chan struct{}
+}
+
+type chatLogFile struct {
+	day  string
+	f    *os.File
+	w    *bufio.Writer
+	used time.Time
+}
+
+func NewChatLogger(b *Client, opt ChatLoggerOptions) (*ChatLogger, error) {
+	if opt.Formats == 0 {
+		opt.Formats = LogText | LogJSONL
+	}
+	if opt.Location == nil {
+		opt.Location = time.UTC
+	}
+	if err := os.MkdirAll(opt.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	l := &ChatLogger{
+		opt:   opt,
+		now:   time.Now,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		files: make(map[string]*chatLogFile),
+	}
+	go l.run()
+	if b != nil {
+		l.listen(b)
+	}
+	return l, nil
+}
+
+func (l *ChatLogger) listen(b *Client) {
+	l.handlerRemovers = append(l.handlerRemovers,
+		b.OnPrivateMessage(func(m *PrivateMessage) {
+			target := m.User
+			if m.Self {
+				target = m.Recipient
+			}
+			l.logMessage(target.Name(), m.User.Name(), &m.message)
+		}),
+		b.OnChannelMessage(func(m *ChannelMessage) {
+			l.logMessage(m.Channel.Name(), m.User.Name(), &m.message)
+		}),
+		b.OnJoin(func(m *ChannelMember) {
+			l.write(ChatLogEntry{Type: LogJoin, Target: m.Channel.Name(), User: m.User.Name()})
+		}),
+		b.OnPart(func(m *ChannelMember) {
+			l.write(ChatLogEntry{Type: LogPart, Target: m.Channel.Name(), User: m.User.Name()})
+		}),
+		// Quit is emitted before the user is removed from channels, so we know where to log it
+		b.OnQuit(func(u *User) {
+			b.Channels.Range(func(name string, c *Channel) bool {
+				if _, ok := c.Members.Load(u.Name()); ok {
+					l.write(ChatLogEntry{Type: LogQuit, Target: name, User: u.Name()})
+				}
+				return true
+			})
+		}),
+		b.OnTopic(func(c *Channel) {
+			l.write(ChatLogEntry{Type: LogTopic, Target: c.Name(), Content: c.Topic})
+		}),
+	)
+}
+
+func (l *ChatLogger) logMessage(target, user string, m *message) {
+	e := ChatLogEntry{Type: LogMessage, Target: target, User: user, Content: m.Content()}
+	if m.IsAction() {
+		e.Type = LogAction
+		e.Content = m.Action()
+	}
+	l.write(e)
+}
+
+func (l *ChatLogger) write(e ChatLogEntry) {
+	// Event handlers have nowhere to return the error, write errors are reported by Err
+	_ = l.Log(e)
+}
+
+// Log queues an entry, zero Time is replaced with the current time. Write errors are reported by Err.
+func (l *ChatLogger) Log(e ChatLogEntry) error {
+	if l.opt.Filter != nil && !l.opt.Filter(e.Target) {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	e.Time = e.Time.In(l.opt.Location)
+
+	var records []chatLogRecord
+	for format, ext := range logExtensions {
+		if l.opt.Formats&format == 0 {
+			continue
+		}
+
+		var line []byte
+		if format == LogJSONL {
+			var err error
+			if line, err = json.Marshal(e); err != nil {
+				return err
+			}
+		} else {
+			line = []byte(e.text())
+		}
+		records = append(records, chatLogRecord{target: e.Target, ext: ext, time: e.Time, data: append(line, '\n')})
+	}
+
+	if !l.enqueue(records...) {
+		return ErrChatLoggerClosed
+	}
+	return nil
+}
+
+// enqueue adds records for the writer goroutine, ok is false if the logger is closed
+func (l *ChatLogger) enqueue(records ...chatLogRecord) (ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false
+	}
+	l.queue = append(l.queue, records...)
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Flush waits until all logged entries are written
+func (l *ChatLogger) Flush() error {
+	flushed := make(chan struct{})
+	if l.enqueue(chatLogRecord{flushed: flushed}) {
+		<-flushed
+	}
+	return l.Err()
+}
+
+// Err returns the first write error
+func (l *ChatLogger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *ChatLogger) setErr(err error) {
+	if err == nil {
+		return
+	}
+	l.mu.Lock()
+	if l.err == nil {
+		l.err = err
+	}
+	l.mu.Unlock()
+}
+
+func (l *ChatLogger) run() {
+	for range l.wake {
+		l.mu.Lock()
+		queue := l.queue
+		l.queue = nil
+		l.mu.Unlock()
+
+		// Lines are buffered and every batch is flushed at once
+		for _, r := range queue {
+			if r.flushed == nil {
+				l.writeRecord(r)
+			}
+		}
+		l.flushFiles()
+		for _, r := range queue {
+			if r.flushed != nil {
+				close(r.flushed)
+			}
+		}
+	}
+
+	l.closeFiles()
+	close(l.done)
+}
+
+func (l *ChatLogger) writeRecord(r chatLogRecord) {
+	f, err := l.file(r.target, r.ext, r.time)
+	if err != nil {
+		l.setErr(err)
+		return
+	}
+	_, err = f.w.Write(r.data)
+	l.setErr(err)
+	f.used = time.Now()
+}
+
+// file returns an opened file for the target and day, rotating the previous one
+func (l *ChatLogger) file(target, ext string, t time.Time) (*chatLogFile, error) {
+	day := t.Format("2006-01-02")
+	dir := filepath.Join(l.opt.Dir, logDirName(target))
+	key := dir + ext
+
+	if f, ok := l.files[key]; ok {
+		if f.day == day {
+			return f, nil
+		}
+		l.closeFile(key, f)
+	}
+	if len(l.files) >= maxOpenChatLogFiles {
+		l.closeFiles()
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, day+ext), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	f := &chatLogFile{day: day, f: file, w: bufio.NewWriter(file)}
+	l.files[key] = f
+
+	l.cleanup(dir, ext, t)
+	return f, nil
+}
+
+// flushFiles writes buffered lines and closes files which weren't used for chatLogIdleTimeout
+func (l *ChatLogger) flushFiles() {
+	for key, f := range l.files {
+		if time.Since(f.used) > chatLogIdleTimeout {
+			l.closeFile(key, f)
+		} else {
+			l.setErr(f.w.Flush())
+		}
+	}
+}
+
+func (l *ChatLogger) closeFile(key string, f *chatLogFile) {
+	l.setErr(f.w.Flush())
+	l.setErr(f.f.Close())
+	delete(l.files, key)
+}
+
+// closeFiles closes every open file, targets are reopened on the next entry
+func (l *ChatLogger) closeFiles() {
+	for key, f := range l.files {
+		l.closeFile(key, f)
+	}
+}
+
+// cleanup removes files exceeding retention limits, file names are dates so they sort chronologically
+func (l *ChatLogger) cleanup(dir, ext string, now time.Time) {
+	if l.opt.MaxAge <= 0 && l.opt.MaxFiles <= 0 {
+		return
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+	sort.Strings(names)
+
+	for i, name := range names {
+		remove := l.opt.MaxFiles > 0 && i < len(names)-l.opt.MaxFiles
+		if !remove && l.opt.MaxAge > 0 {
+			day, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(filepath.Base(name), ext), l.opt.Location)
+			remove = err == nil && now.Sub(day.AddDate(0, 0, 1)) > l.opt.MaxAge
+		}
+		if remove {
+			os.Remove(name)
+		}
+	}
+}
+
+// Close stops listening to client events, writes logged entries and closes files
+func (l *ChatLogger) Close() error {
+	for _, remove := range l.handlerRemovers {
+		remove()
+	}
+	l.handlerRemovers = nil
+
+	l.Flush()
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.wake)
+	}
+	l.mu.Unlock()
+	<-l.done
+	return l.Err()
+}
+
+func logDirName(target string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.ToLower(target))
+}
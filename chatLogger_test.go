@@ -0,0 +1,109 @@
+package banchogo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatLogger(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+
+	l, err := NewChatLogger(b, ChatLoggerOptions{Dir: dir, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	feedLine(b, ":peppy!cho@ppy.sh JOIN :#osu")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :hello world")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :\x01ACTION waves\x01")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG bot :hi bot")
+
+	now = now.AddDate(0, 0, 1)
+	feedLine(b, ":peppy!cho@ppy.sh QUIT :quit")
+	now = now.AddDate(0, 0, 1)
+	feedLine(b, ":cho.ppy.sh 332 bot #osu :some topic")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "#osu", "2022-01-01.log"))
+	if !os.IsNotExist(err) {
+		t.Errorf("the oldest file should be removed by retention, got %q (%v)", text, err)
+	}
+
+	text, err = os.ReadFile(filepath.Join(dir, "#osu", "2022-01-02.log"))
+	if err != nil || string(text) != "[12:00:00] <-- peppy has quit\n" {
+		t.Errorf("unexpected log %q (%v)", text, err)
+	}
+
+	text, err = os.ReadFile(filepath.Join(dir, "peppy", "2022-01-01.jsonl"))
+	want := `{"time":"2022-01-01T12:00:00Z","type":"message","target":"peppy","user":"peppy","content":"hi bot"}` + "\n"
+	if err != nil || string(text) != want {
+		t.Errorf("unexpected private log %q (%v)", text, err)
+	}
+}
+
+func TestChatLogger_OpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewChatLogger(nil, ChatLoggerOptions{Dir: dir, Formats: LogText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.now = func() time.Time { return time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	for i := 0; i <= maxOpenChatLogFiles; i++ {
+		l.Log(ChatLogEntry{Type: LogMessage, Target: fmt.Sprintf("user%d", i), User: "peppy", Content: "hi"})
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.files) > maxOpenChatLogFiles {
+		t.Errorf("expected at most %d open files, got %d", maxOpenChatLogFiles, len(l.files))
+	}
+
+	// Files of quiet conversations are closed after the next batch
+	for _, f := range l.files {
+		f.used = time.Now().Add(-chatLogIdleTimeout - time.Second)
+	}
+	l.Log(ChatLogEntry{Type: LogMessage, Target: "user0", User: "peppy", Content: "again"})
+	l.Flush()
+	if len(l.files) != 1 {
+		t.Errorf("idle files should be closed, %d are open", len(l.files))
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "user0", "2022-01-01.log"))
+	if err != nil || strings.Count(string(text), "\n") != 2 {
+		t.Errorf("unexpected log %q (%v)", text, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := l.Log(ChatLogEntry{Target: "user0"}); err != ErrChatLoggerClosed {
+		t.Errorf("expected ErrChatLoggerClosed, got %v", err)
+	}
+}
+
+func TestChatLogEntry_Text(t *testing.T) {
+	ts := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := map[string]ChatLogEntry{
+		"[12:00:00] <peppy> hello":               {Time: ts, Type: LogMessage, Target: "#osu", User: "peppy", Content: "hello"},
+		"[12:00:00] * peppy waves":               {Time: ts, Type: LogAction, Target: "#osu", User: "peppy", Content: "waves"},
+		"[12:00:00] --> peppy has joined #osu":   {Time: ts, Type: LogJoin, Target: "#osu", User: "peppy"},
+		"[12:00:00] -- Topic for #osu is: topic": {Time: ts, Type: LogTopic, Target: "#osu", Content: "topic"},
+	}
+	for want, e := range entries {
+		if got := e.text(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/puzpuzpuz/xsync/v2"
+	"github.com/robloxxa/banchogo/apiv2"
 	"github.com/thehowl/go-osuapi"
 	"go.uber.org/ratelimit"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"net/textproto"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -52,8 +54,28 @@ type ClientOptions struct {
 	Reconnect  *bool
 
 	ApiKey string
+	// ApiV2 is used instead of the legacy API when set
+	ApiV2 *apiv2.Client
+	// Cache is used for API lookups, by default it's an in-memory cache with 5 minutes TTL
+	Cache *APICache
 
 	RateLimiter ratelimit.Limiter
+
+	Dial func(network, address string) (net.Conn, error)
+
+	// CTCPReplies enables automatic replies to CTCP VERSION, PING, TIME and CLIENTINFO queries
+	CTCPReplies bool
+	// CTCPVersion is the reply to VERSION queries, DefaultCTCPVersion if empty
+	CTCPVersion string
+
+	// HistorySize is how many messages are kept per channel and private conversation,
+	// DefaultHistorySize if zero. Negative value disables history.
+	HistorySize int
+	// HistoryStore persists history, e.g. FileHistoryStore. History is kept only in memory if nil.
+	HistoryStore HistoryStore
+
+	// Metrics receives client measurements, e.g. metrics.Collector. Nothing is measured if nil.
+	Metrics Metrics
 }
 
 type Client struct {
@@ -61,6 +83,9 @@ type Client struct {
 	ev EventEmitter
 
 	Api osuapi.Client
+	// ApiV2 is osu! API v2 client, when set User and Lobby fetch data with it instead of the legacy Api
+	ApiV2 *apiv2.Client
+	Cache *APICache
 
 	Username string
 	Password string
@@ -82,20 +107,45 @@ type Client struct {
 	// You can initialize limiter with non-default values or use your own limiter that implements Limiter interface
 	RateLimiter ratelimit.Limiter
 
+	// Dial is used to connect to the server instead of net.Dial, e.g. to replay a Transcript
+	Dial func(network, address string) (net.Conn, error)
+
+	// CTCPReplies enables automatic replies to CTCP VERSION, PING, TIME and CLIENTINFO queries in private messages
+	CTCPReplies bool
+	// CTCPVersion is the reply to VERSION queries, DefaultCTCPVersion if empty
+	CTCPVersion string
+
+	// HistorySize and HistoryStore configure Channel.History and User.History, they must be set before any message is received
+	HistorySize  int
+	HistoryStore HistoryStore
+
+	// Metrics receives client measurements, it must be set before Connect
+	Metrics Metrics
+
 	// TODO: check for data race when editing user/channel objects
 	Users    *xsync.MapOf[string, *User]
 	Channels *xsync.MapOf[string, *Channel]
+	Lobbies  *xsync.MapOf[string, *Lobby]
 
 	conn net.Conn
 
 	stateMutex   sync.RWMutex
 	connectState ConnectState
 
-	currentWhois *WhoisResponse
+	whoisMu       sync.Mutex
+	whoisRequests map[string]*whoisRequest
+
+	listMu      sync.Mutex
+	listRequest *channelListRequest
+
+	rollMu       sync.Mutex
+	pendingRolls map[string]pendingRoll
 
 	messageQueue    chan *OutgoingMessage
 	reconnectSignal chan struct{}
 	connectSignal   chan error
+	// queued is the number of messages waiting to be taken from messageQueue
+	queued atomic.Int32
 
 	Done chan struct{}
 }
@@ -105,7 +155,23 @@ func NewBanchoClient(opt ClientOptions) (b *Client) {
 		Username:   opt.Username,
 		Password:   opt.Password,
 		BotAccount: opt.BotAccount,
+		Dial:       opt.Dial,
+		ApiV2:      opt.ApiV2,
+		Cache:      opt.Cache,
+
+		CTCPReplies: opt.CTCPReplies,
+		CTCPVersion: opt.CTCPVersion,
+
+		HistorySize:  opt.HistorySize,
+		HistoryStore: opt.HistoryStore,
+
+		Metrics: opt.Metrics,
+
+		Users:    xsync.NewMapOf[*User](),
+		Channels: xsync.NewMapOf[*Channel](),
+		Lobbies:  xsync.NewMapOf[*Lobby](),
 	}
+	b.ev.observe = b.observeHandler
 
 	if opt.RateLimiter == nil {
 		var (
@@ -124,6 +190,10 @@ func NewBanchoClient(opt ClientOptions) (b *Client) {
 		b.RateLimiter.Take() // Init Ratelimiter
 	}
 
+	if b.Cache == nil {
+		b.Cache = NewAPICache(APICacheOptions{})
+	}
+
 	if opt.ApiKey != "" {
 		b.Api = *osuapi.NewClient(opt.ApiKey)
 	}
@@ -155,11 +225,18 @@ func (b *Client) Connect() (err error) {
 	if b.Channels == nil {
 		b.Channels = xsync.NewMapOf[*Channel]()
 	}
+	if b.Lobbies == nil {
+		b.Lobbies = xsync.NewMapOf[*Lobby]()
+	}
 	if b.reconnectSignal == nil {
 		b.reconnectSignal = make(chan struct{})
 	}
 
-	b.conn, err = net.Dial("tcp", b.Host+":"+b.Port)
+	dial := b.Dial
+	if dial == nil {
+		dial = net.Dial
+	}
+	b.conn, err = dial("tcp", b.Host+":"+b.Port)
 	if err != nil {
 		return err
 	}
@@ -225,6 +302,9 @@ func (b *Client) reconnect() {
 	}
 
 	b.Disconnect()
+	if b.Metrics != nil {
+		b.Metrics.Reconnect()
+	}
 	for {
 		err := b.Connect()
 
@@ -308,6 +388,7 @@ func (b *Client) readIrcMessages(conn net.Conn, done <-chan struct{}) {
 			return
 		default:
 			splits := strings.Split(content, " ")
+			b.observeInboundLine(splits)
 			b.ev.Emit("RawMessage", splits)
 			if splits[0] == "PING" {
 				b.ev.Emit("PING")
@@ -333,6 +414,7 @@ func (b *Client) processMessages(messageQueue <-chan *OutgoingMessage) {
 	defer func() {
 		close(b.messageQueue)
 		for msg := range b.messageQueue {
+			b.addQueued(-1)
 			msg.C <- ErrConnectionClosed
 		}
 		b.messageQueue = nil
@@ -340,13 +422,18 @@ func (b *Client) processMessages(messageQueue <-chan *OutgoingMessage) {
 	for {
 		select {
 		case msg := <-messageQueue:
+			b.addQueued(-1)
 			if !b.IsConnected() {
 				msg.C <- errors.New("currently disconnected")
 				break
 			}
 
 			if b.RateLimiter != nil {
+				start := time.Now()
 				b.RateLimiter.Take()
+				if b.Metrics != nil {
+					b.Metrics.RateLimitWait(time.Since(start))
+				}
 			}
 
 			name := TruncateString(strings.Split(msg.Name(), "\n")[0], 28)
@@ -355,17 +442,38 @@ func (b *Client) processMessages(messageQueue <-chan *OutgoingMessage) {
 			if b.BotAccount && msg.Type() == "channel" {
 				msg.C <- errors.New("bot accounts aren't allowed to send messages in channels")
 			}
-			err := b.Send(fmt.Sprintf("PRIVMSG %s :%s", name, content))
+			command := "PRIVMSG"
+			if msg.notice {
+				command = "NOTICE"
+			} else {
+				b.trackOutgoingRoll(msg.MessageSender, content)
+			}
+			err := b.Send(fmt.Sprintf("%s %s :%s", command, name, content))
 			if err != nil {
 				msg.C <- err
 				break
 			}
+			if b.Metrics != nil {
+				b.Metrics.OutboundMessage(msg.Type())
+			}
+			if msg.notice {
+				msg.C <- nil
+				break
+			}
 
 			switch s := msg.MessageSender.(type) {
 			case *User:
-				b.ev.Emit("PrivateMessage", newPrivateMessage(b, b.GetSelf(), s, true, content))
+				pm := newPrivateMessage(b, b.GetSelf(), s, true, content)
+				b.recordPrivateMessage(pm)
+				b.ev.Emit("PrivateMessage", pm)
 			case *Channel:
-				b.ev.Emit("ChannelMessage", newChannelMessage(b, b.GetSelf(), s, true, content))
+				cm := newChannelMessage(b, b.GetSelf(), s, true, content)
+				b.recordChannelMessage(cm)
+				b.ev.Emit("ChannelMessage", cm)
+			case *Lobby:
+				cm := newChannelMessage(b, b.GetSelf(), s.Channel, true, content)
+				b.recordChannelMessage(cm)
+				b.ev.Emit("ChannelMessage", cm)
 			}
 
 			msg.C <- nil
@@ -421,7 +529,7 @@ func (b *Client) setConnectState(state ConnectState) {
 		b.ev.Emit("Disconnect", nil)
 	}
 
-	b.ev.Emit("StateChanged", state)
+	b.ev.Emit("ConnectState", state)
 }
 
 func (b *Client) IsDisconnected() bool {
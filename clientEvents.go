@@ -64,6 +64,33 @@ func (b *Client) OnceMessage(handler func(Message)) func() {
 	return b.ev.Once("Message", handler)
 }
 
+// OnRoll is called for every BanchoBot's answer to "!roll" seen by the client
+func (b *Client) OnRoll(handler func(*RollResult)) func() {
+	return b.ev.On("Roll", handler)
+}
+
+func (b *Client) OnceRoll(handler func(*RollResult)) func() {
+	return b.ev.Once("Roll", handler)
+}
+
+// OnCTCP is called for every received CTCP message, including actions
+func (b *Client) OnCTCP(handler func(Message, *CTCP)) func() {
+	return b.ev.On("CTCP", handler)
+}
+
+func (b *Client) OnceCTCP(handler func(Message, *CTCP)) func() {
+	return b.ev.Once("CTCP", handler)
+}
+
+// OnNowPlaying is called for every private or channel message sent with /np
+func (b *Client) OnNowPlaying(handler func(Message, *NowPlaying)) func() {
+	return b.ev.On("NowPlaying", handler)
+}
+
+func (b *Client) OnceNowPlaying(handler func(Message, *NowPlaying)) func() {
+	return b.ev.Once("NowPlaying", handler)
+}
+
 func (b *Client) OnJoin(handler func(*ChannelMember)) func() {
 	return b.ev.On("Join", handler)
 }
@@ -80,6 +107,15 @@ func (b *Client) OncePart(handler func(*ChannelMember)) func() {
 	return b.ev.Once("Part", handler)
 }
 
+// OnModeChange is called when a channel member gets or loses moderator or voice mode
+func (b *Client) OnModeChange(handler func(member *ChannelMember, old, new ChannelMemberMode)) func() {
+	return b.ev.On("ModeChange", handler)
+}
+
+func (b *Client) OnceModeChange(handler func(member *ChannelMember, old, new ChannelMemberMode)) func() {
+	return b.ev.Once("ModeChange", handler)
+}
+
 func (b *Client) OnQuit(handler func(*User)) func() {
 	return b.ev.On("Quit", handler)
 }
@@ -87,3 +123,12 @@ func (b *Client) OnQuit(handler func(*User)) func() {
 func (b *Client) OnceQuit(handler func(*User)) func() {
 	return b.ev.Once("Quit", handler)
 }
+
+// OnTopic is emitted when the server sends a channel topic, usually after joining the channel
+func (b *Client) OnTopic(handler func(*Channel)) func() {
+	return b.ev.On("Topic", handler)
+}
+
+func (b *Client) OnceTopic(handler func(*Channel)) func() {
+	return b.ev.Once("Topic", handler)
+}
@@ -0,0 +1,387 @@
+package banchogo
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultPoolMaxLobbies is how many tournament lobbies a regular account can have open at once
+const DefaultPoolMaxLobbies = 4
+
+var ErrNoAvailableClient = errors.New("no connected client available in the pool")
+
+// PoolOptions configures a ClientPool
+type PoolOptions struct {
+	// MaxLobbies is how many lobbies a single account may create, DefaultPoolMaxLobbies if 0
+	MaxLobbies int
+	// AddReferees adds every other account of the pool as a referee of created lobbies.
+	// Lobbies are taken over only by their referees, so without it they aren't taken over at all.
+	AddReferees bool
+}
+
+// poolMember is a pool client with its load
+type poolMember struct {
+	client *Client
+	// lobbies is the number of open lobbies created by the client
+	lobbies int
+	// queued is the number of private messages sent through the pool which wait for the client's rate limiter
+	queued int
+}
+
+// load is the number of messages the client has to send before a new one.
+// The client's queue includes messages the pool passed to it already, so the larger of both is used.
+func (m *poolMember) load() int {
+	if q := int(m.client.queued.Load()); q > m.queued {
+		return q
+	}
+	return m.queued
+}
+
+// rate returns how many messages per second the account may send, see ClientOptions.BotAccount
+func (m *poolMember) rate() float64 {
+	if m.client.BotAccount {
+		return 298 / 62.5
+	}
+	return 9 / 12.5
+}
+
+// poolLobby is a lobby created by the pool, lobby changes when another client takes it over
+type poolLobby struct {
+	lobby   *Lobby
+	creator *poolMember
+	// referees are clients which can take the lobby over: the creator and clients added as referees
+	referees map[*Client]bool
+}
+
+// ClientPool spreads lobbies and private messages across several accounts, e.g. referee or bot accounts
+// of a large tournament, so a single account's rate limit and lobby cap aren't a bottleneck.
+// When a client disconnects, its lobbies are taken over by other clients of the pool.
+type ClientPool struct {
+	ev EventEmitter
+
+	opt PoolOptions
+
+	mu      sync.Mutex
+	members []*poolMember
+	lobbies map[int]*poolLobby
+	// closing is set by Disconnect, so clients disconnected on purpose don't fail over
+	closing bool
+}
+
+func NewClientPool(opt PoolOptions, clients ...*Client) *ClientPool {
+	if opt.MaxLobbies == 0 {
+		opt.MaxLobbies = DefaultPoolMaxLobbies
+	}
+	p := &ClientPool{opt: opt, lobbies: make(map[int]*poolLobby)}
+	for _, c := range clients {
+		p.Add(c)
+	}
+	return p
+}
+
+// Add adds a client to the pool, it can be connected already
+func (p *ClientPool) Add(c *Client) {
+	p.mu.Lock()
+	p.members = append(p.members, &poolMember{client: c})
+	p.mu.Unlock()
+
+	c.OnMessage(func(m Message) {
+		if cm, ok := m.(*ChannelMessage); ok && !p.isPrimary(c, cm.Channel.Name()) {
+			return
+		}
+		p.ev.Emit("Message", c, m)
+	})
+	c.OnConnectState(func(state ConnectState) {
+		p.ev.Emit("ConnectState", c, state)
+		if state == Disconnected && !p.isClosing() {
+			go p.failover(c)
+		}
+	})
+}
+
+// Clients returns all clients of the pool in order they were added
+func (p *ClientPool) Clients() []*Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clients := make([]*Client, len(p.members))
+	for i, m := range p.members {
+		clients[i] = m.client
+	}
+	return clients
+}
+
+// Connect connects every client which isn't connected yet, returns the first error
+func (p *ClientPool) Connect() error {
+	p.mu.Lock()
+	p.closing = false
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, c := range p.Clients() {
+		if c.IsConnected() {
+			continue
+		}
+		if err := c.Connect(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Disconnect disconnects every client, their lobbies aren't taken over
+func (p *ClientPool) Disconnect() {
+	p.mu.Lock()
+	p.closing = true
+	p.mu.Unlock()
+
+	for _, c := range p.Clients() {
+		c.Disconnect()
+	}
+}
+
+func (p *ClientPool) isClosing() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closing
+}
+
+// CreateLobby creates a lobby with the connected client which has the fewest open lobbies.
+// Returns ErrNoAvailableClient if every connected client reached PoolOptions.MaxLobbies.
+func (p *ClientPool) CreateLobby(name string) <-chan CreateLobbyResponse {
+	resp := make(chan CreateLobbyResponse, 1)
+
+	// The slot is taken before the lobby is created, so concurrent calls don't pick the same client
+	p.mu.Lock()
+	var owner *poolMember
+	for _, m := range p.members {
+		if m.client.IsConnected() && m.lobbies < p.opt.MaxLobbies && (owner == nil || m.lobbies < owner.lobbies) {
+			owner = m
+		}
+	}
+	if owner != nil {
+		owner.lobbies++
+	}
+	p.mu.Unlock()
+
+	if owner == nil {
+		resp <- CreateLobbyResponse{Error: ErrNoAvailableClient}
+		return resp
+	}
+
+	go func() {
+		r := <-owner.client.CreateLobby(name)
+		if r.Error != nil {
+			p.mu.Lock()
+			owner.lobbies--
+			p.mu.Unlock()
+			resp <- r
+			return
+		}
+
+		pl := &poolLobby{lobby: r.Lobby, creator: owner, referees: map[*Client]bool{owner.client: true}}
+		p.mu.Lock()
+		p.lobbies[r.Lobby.Id] = pl
+		p.mu.Unlock()
+		p.watchLobby(pl, r.Lobby)
+
+		if p.opt.AddReferees {
+			for _, c := range p.Clients() {
+				if c == owner.client {
+					continue
+				}
+				if err := r.Lobby.AddReferee(r.Lobby.Client.GetUser(c.Username)); err != nil {
+					p.ev.Emit("Error", err)
+					continue
+				}
+				p.mu.Lock()
+				pl.referees[c] = true
+				p.mu.Unlock()
+			}
+		}
+		resp <- r
+	}()
+
+	return resp
+}
+
+// watchLobby frees the creator's lobby slot when the lobby is closed
+func (p *ClientPool) watchLobby(pl *poolLobby, l *Lobby) {
+	l.OnceClosed(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if pl.lobby == l && p.lobbies[l.Id] == pl {
+			delete(p.lobbies, l.Id)
+			pl.creator.lobbies--
+		}
+	})
+}
+
+// Lobby returns the lobby created by the pool, nil if there is no such open lobby.
+// After a failover it belongs to another client, so don't keep the returned object for long.
+func (p *ClientPool) Lobby(id int) *Lobby {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pl, ok := p.lobbies[id]; ok {
+		return pl.lobby
+	}
+	return nil
+}
+
+// Lobbies returns all open lobbies created by the pool
+func (p *ClientPool) Lobbies() []*Lobby {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lobbies := make([]*Lobby, 0, len(p.lobbies))
+	for _, pl := range p.lobbies {
+		lobbies = append(lobbies, pl.lobby)
+	}
+	return lobbies
+}
+
+// SendPrivateMessage sends a private message with the connected client which will send it soonest,
+// based on messages already queued by the client and the account's rate limit
+func (p *ClientPool) SendPrivateMessage(username, message string) error {
+	sender := p.reserveSender()
+	if sender == nil {
+		return ErrNoAvailableClient
+	}
+	defer func() {
+		p.mu.Lock()
+		sender.queued--
+		p.mu.Unlock()
+	}()
+	return sender.client.GetUser(username).SendMessage(message)
+}
+
+// reserveSender picks the client for a private message and counts the message in its queue
+func (p *ClientPool) reserveSender() *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var sender *poolMember
+	for _, m := range p.members {
+		if !m.client.IsConnected() {
+			continue
+		}
+		if sender == nil || float64(m.load()+1)/m.rate() < float64(sender.load()+1)/sender.rate() {
+			sender = m
+		}
+	}
+	if sender != nil {
+		sender.queued++
+	}
+	return sender
+}
+
+// isPrimary reports whether the client forwards messages of the channel to the merged stream.
+// Every pool client in a channel receives its messages, only the first connected one is forwarded.
+func (p *ClientPool) isPrimary(c *Client, channelName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, m := range p.members {
+		if !m.client.IsConnected() {
+			continue
+		}
+		if ch, ok := m.client.Channels.Load(channelName); ok && ch.Joined {
+			return m.client == c
+		}
+	}
+	return true
+}
+
+// failover moves lobbies of the disconnected client to other connected referees of the pool
+func (p *ClientPool) failover(failed *Client) {
+	p.mu.Lock()
+	var moved []*poolLobby
+	for _, pl := range p.lobbies {
+		if pl.lobby.Client == failed {
+			moved = append(moved, pl)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pl := range moved {
+		if p.isClosing() {
+			return
+		}
+		target := p.failoverTarget(pl, failed)
+		if target == nil {
+			p.ev.Emit("Error", ErrNoAvailableClient)
+			continue
+		}
+
+		old := pl.lobby
+		l := target.GetLobby(old.Id)
+		if err := <-l.Channel.Join(); err != nil {
+			p.ev.Emit("Error", err)
+			continue
+		}
+
+		p.mu.Lock()
+		if pl.lobby != old || p.lobbies[old.Id] != pl {
+			p.mu.Unlock()
+			continue
+		}
+		pl.lobby = l
+		p.mu.Unlock()
+
+		p.watchLobby(pl, l)
+		p.ev.Emit("LobbyFailover", old, l)
+	}
+}
+
+// failoverTarget returns the connected referee of the lobby serving the fewest pool lobbies.
+// Taken over lobbies don't count towards MaxLobbies, Bancho limits only lobbies made by the account.
+func (p *ClientPool) failoverTarget(pl *poolLobby, failed *Client) *Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	served := make(map[*Client]int)
+	for _, pl := range p.lobbies {
+		served[pl.lobby.Client]++
+	}
+	var target *Client
+	for _, m := range p.members {
+		if m.client == failed || !m.client.IsConnected() || !pl.referees[m.client] {
+			continue
+		}
+		if target == nil || served[m.client] < served[target] {
+			target = m.client
+		}
+	}
+	return target
+}
+
+// OnMessage is called for messages received by any client of the pool. Channel messages seen by
+// several clients are passed once, private messages are passed with the client that received them.
+func (p *ClientPool) OnMessage(handler func(*Client, Message)) func() {
+	return p.ev.On("Message", handler)
+}
+
+func (p *ClientPool) OnceMessage(handler func(*Client, Message)) func() {
+	return p.ev.Once("Message", handler)
+}
+
+func (p *ClientPool) OnConnectState(handler func(*Client, ConnectState)) func() {
+	return p.ev.On("ConnectState", handler)
+}
+
+func (p *ClientPool) OnceConnectState(handler func(*Client, ConnectState)) func() {
+	return p.ev.Once("ConnectState", handler)
+}
+
+// OnLobbyFailover is called when another client takes over a lobby of a disconnected client
+func (p *ClientPool) OnLobbyFailover(handler func(old, new *Lobby)) func() {
+	return p.ev.On("LobbyFailover", handler)
+}
+
+func (p *ClientPool) OnceLobbyFailover(handler func(old, new *Lobby)) func() {
+	return p.ev.Once("LobbyFailover", handler)
+}
+
+func (p *ClientPool) OnError(handler func(error)) func() {
+	return p.ev.On("Error", handler)
+}
+
+func (p *ClientPool) OnceError(handler func(error)) func() {
+	return p.ev.Once("Error", handler)
+}
@@ -0,0 +1,126 @@
+package banchogo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientPool_CreateLobbyAndFailover(t *testing.T) {
+	ref1, conn1 := newReplayClient(t, ClientOptions{Username: "ref1"},
+		"300\t:BanchoBot!cho@ppy.sh PRIVMSG ref1 :Created the tournament match https://osu.ppy.sh/mp/1 first")
+	ref2, conn2 := newReplayClient(t, ClientOptions{Username: "ref2"},
+		"300\t:BanchoBot!cho@ppy.sh PRIVMSG ref2 :Created the tournament match https://osu.ppy.sh/mp/2 second",
+		"1500\t:ref2!cho@ppy.sh JOIN :#mp_1")
+
+	pool := NewClientPool(PoolOptions{MaxLobbies: 1, AddReferees: true}, ref1, ref2)
+	if err := pool.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Disconnect()
+
+	first, second := pool.CreateLobby("first"), pool.CreateLobby("second")
+	if resp := <-pool.CreateLobby("third"); resp.Error != ErrNoAvailableClient {
+		t.Errorf("expected ErrNoAvailableClient for the third lobby, got %v", resp.Error)
+	}
+	for _, ch := range []<-chan CreateLobbyResponse{first, second} {
+		if resp := <-ch; resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+	}
+	if l := pool.Lobby(1); l == nil || l.Client != ref1 || l.RoomName() != "first" {
+		t.Fatalf("lobby 1 should be created by ref1, got %+v", l)
+	}
+	if l := pool.Lobby(2); l == nil || l.Client != ref2 {
+		t.Fatalf("lobby 2 should be created by ref2, got %+v", l)
+	}
+	if sent := conn1.Sent()[3:]; len(sent) != 2 || sent[1] != "PRIVMSG #mp_1 :!mp addref ref2" {
+		t.Errorf("unexpected lines sent by ref1 %q", sent)
+	}
+
+	failover := make(chan [2]*Lobby, 1)
+	pool.OnLobbyFailover(func(old, new *Lobby) { failover <- [2]*Lobby{old, new} })
+	ref1.Disconnect()
+
+	select {
+	case lobbies := <-failover:
+		if lobbies[0].Client != ref1 || lobbies[1].Client != ref2 || lobbies[1].Id != 1 {
+			t.Errorf("unexpected failover from %+v to %+v", lobbies[0], lobbies[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lobby wasn't taken over")
+	}
+	if l := pool.Lobby(1); l.Client != ref2 {
+		t.Errorf("lobby 1 should belong to ref2 after failover")
+	}
+	if sent := conn2.Sent(); sent[len(sent)-1] != "JOIN #mp_1" {
+		t.Errorf("ref2 should join the lobby, sent %q", sent)
+	}
+}
+
+func TestClientPool_MergedMessages(t *testing.T) {
+	ref1 := NewBanchoClient(ClientOptions{Username: "ref1"})
+	ref2 := NewBanchoClient(ClientOptions{Username: "ref2"})
+	pool := NewClientPool(PoolOptions{}, ref1, ref2)
+
+	var got []string
+	pool.OnMessage(func(c *Client, m Message) {
+		got = append(got, c.Username+": "+m.Content())
+	})
+
+	for _, b := range []*Client{ref1, ref2} {
+		b.setConnectState(Connected)
+		feedLine(b, ":"+b.Username+"!cho@ppy.sh JOIN :#osu")
+		feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :hello")
+		feedLine(b, ":peppy!cho@ppy.sh PRIVMSG "+b.Username+" :hi "+b.Username)
+	}
+
+	// ref1 is the first connected client in #osu, so only it passes channel messages
+	expected := []string{"ref1: hello", "ref1: hi ref1", "ref2: hi ref2"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	ref1.setConnectState(Disconnected)
+	got = nil
+	feedLine(ref2, ":peppy!cho@ppy.sh PRIVMSG #osu :still here")
+	if len(got) != 1 || got[0] != "ref2: still here" {
+		t.Errorf("ref2 should pass channel messages after ref1 disconnected, got %q", got)
+	}
+}
+
+func TestClientPool_FailoverTargets(t *testing.T) {
+	ref1 := NewBanchoClient(ClientOptions{Username: "ref1"})
+	ref2 := NewBanchoClient(ClientOptions{Username: "ref2"})
+	ref3 := NewBanchoClient(ClientOptions{Username: "ref3"})
+	pool := NewClientPool(PoolOptions{}, ref1, ref2, ref3)
+	for _, c := range pool.Clients() {
+		c.setConnectState(Connected)
+	}
+
+	// Only referees of the lobby can take it over
+	pl := &poolLobby{lobby: ref1.GetLobby(1), creator: pool.members[0], referees: map[*Client]bool{ref1: true, ref3: true}}
+	if target := pool.failoverTarget(pl, ref1); target != ref3 {
+		t.Errorf("expected ref3 to take the lobby over, got %v", target)
+	}
+	pl.referees = map[*Client]bool{ref1: true}
+	if target := pool.failoverTarget(pl, ref1); target != nil {
+		t.Errorf("a client which isn't a referee shouldn't take the lobby over, got %s", target.Username)
+	}
+
+	// Lobbies aren't moved while the pool disconnects
+	pool.lobbies[1] = pl
+	errs := 0
+	pool.OnError(func(error) { errs++ })
+	pool.closing = true
+	pool.failover(ref1)
+	if errs != 0 || pool.Lobby(1).Client != ref1 {
+		t.Errorf("failover shouldn't run while the pool disconnects, %d errors", errs)
+	}
+
+	// Messages queued by the client itself count too
+	ref1.queued.Store(5)
+	if sender := pool.reserveSender(); sender.client != ref2 {
+		t.Errorf("expected ref2 to send the message, got %s", sender.client.Username)
+	}
+}
@@ -0,0 +1,86 @@
+package banchogo
+
+import (
+	"strings"
+	"time"
+)
+
+const ctcpDelimiter = "\x01"
+
+// DefaultCTCPVersion is the reply to CTCP VERSION queries when Client.CTCPVersion is empty
+const DefaultCTCPVersion = "banchogo"
+
+// CTCP is a Client-To-Client Protocol message like "\x01ACTION waves\x01", used for /me actions and queries
+// like VERSION, PING and TIME.
+type CTCP struct {
+	// Command is always upper case, e.g. "ACTION"
+	Command string
+	Params  string
+}
+
+// String encodes the message to be sent as PRIVMSG content
+func (c CTCP) String() string {
+	return EncodeCTCP(c.Command, c.Params)
+}
+
+// EncodeCTCP wraps a CTCP command and its params in delimiters
+func EncodeCTCP(command, params string) string {
+	command = strings.ToUpper(command)
+	if params == "" {
+		return ctcpDelimiter + command + ctcpDelimiter
+	}
+	return ctcpDelimiter + command + " " + params + ctcpDelimiter
+}
+
+// DecodeCTCP parses message content as CTCP, returns nil if it isn't one.
+// The closing delimiter is optional, since some clients don't send it.
+func DecodeCTCP(content string) *CTCP {
+	if !strings.HasPrefix(content, ctcpDelimiter) {
+		return nil
+	}
+	content = strings.TrimSuffix(content[1:], ctcpDelimiter)
+
+	command, params, _ := strings.Cut(content, " ")
+	if command == "" {
+		return nil
+	}
+	return &CTCP{Command: strings.ToUpper(command), Params: params}
+}
+
+// ctcpReply returns an answer to a standard CTCP query, ok is false for unknown queries
+func (b *Client) ctcpReply(query *CTCP) (reply CTCP, ok bool) {
+	switch query.Command {
+	case "VERSION":
+		version := b.CTCPVersion
+		if version == "" {
+			version = DefaultCTCPVersion
+		}
+		return CTCP{"VERSION", version}, true
+	case "PING":
+		return CTCP{"PING", query.Params}, true
+	case "TIME":
+		return CTCP{"TIME", time.Now().Format(time.RFC1123Z)}, true
+	case "CLIENTINFO":
+		return CTCP{"CLIENTINFO", "ACTION CLIENTINFO PING TIME VERSION"}, true
+	}
+	return CTCP{}, false
+}
+
+// handleCTCPQuery answers queries sent in private messages when Client.CTCPReplies is enabled.
+// Replies are sent as NOTICE, so two clients never answer each other in a loop.
+func (b *Client) handleCTCPQuery(m *PrivateMessage) {
+	if !b.CTCPReplies || m.Self {
+		return
+	}
+	query := m.CTCP()
+	if query == nil {
+		return
+	}
+	reply, ok := b.ctcpReply(query)
+	if !ok {
+		return
+	}
+
+	// Send blocks until the reply leaves the queue, the reader goroutine can't wait for it
+	go newOutgoingNotice(b, m.User, reply.String()).Send()
+}
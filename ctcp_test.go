@@ -0,0 +1,87 @@
+package banchogo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeCTCP(t *testing.T) {
+	tests := []struct {
+		content string
+		want    *CTCP
+	}{
+		{"\x01ACTION waves\x01", &CTCP{"ACTION", "waves"}},
+		{"\x01version\x01", &CTCP{"VERSION", ""}},
+		{"\x01PING 12345", &CTCP{"PING", "12345"}},
+		{"\x01\x01", nil},
+		{"hello", nil},
+	}
+	for _, tt := range tests {
+		if got := DecodeCTCP(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DecodeCTCP(%q) = %+v, want %+v", tt.content, got, tt.want)
+		}
+	}
+
+	if s := EncodeCTCP("action", "waves"); s != "\x01ACTION waves\x01" {
+		t.Errorf("unexpected encoded action %q", s)
+	}
+	if s := (CTCP{Command: "VERSION"}).String(); s != "\x01VERSION\x01" {
+		t.Errorf("unexpected encoded query %q", s)
+	}
+}
+
+func TestMessage_IsAction(t *testing.T) {
+	m := newPrivateMessage(nil, nil, nil, false, "\x01ACTION waves\x01")
+	if !m.IsAction() || m.Action() != "waves" {
+		t.Errorf("expected an action, got %q", m.Action())
+	}
+
+	m = newPrivateMessage(nil, nil, nil, false, "\x01VERSION\x01")
+	if m.IsAction() || m.Action() != "" || m.CTCP().Command != "VERSION" {
+		t.Errorf("expected a VERSION query, got %+v", m.CTCP())
+	}
+}
+
+func TestClient_CTCPReplies(t *testing.T) {
+	b, conn := newReplayClient(t, ClientOptions{CTCPReplies: true, CTCPVersion: "test bot 1.0"},
+		":peppy!cho@ppy.sh PRIVMSG bot :\x01VERSION\x01",
+		":peppy!cho@ppy.sh PRIVMSG bot :\x01PING 123\x01",
+		":peppy!cho@ppy.sh PRIVMSG #osu :\x01VERSION\x01")
+
+	queries := 0
+	b.OnCTCP(func(m Message, c *CTCP) { queries++ })
+
+	connectTestClient(t, b)
+	<-conn.Done()
+
+	// Replies are sent from separate goroutines, so their order isn't defined
+	var sent []string
+	for deadline := time.Now().Add(5 * time.Second); len(sent) < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		sent = conn.Sent()[3:]
+	}
+	if !reflect.DeepEqual(sent, []string{"NOTICE peppy :\x01VERSION test bot 1.0\x01", "NOTICE peppy :\x01PING 123\x01"}) &&
+		!reflect.DeepEqual(sent, []string{"NOTICE peppy :\x01PING 123\x01", "NOTICE peppy :\x01VERSION test bot 1.0\x01"}) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+	if queries != 3 {
+		t.Errorf("expected 3 CTCP events, got %d", queries)
+	}
+}
+
+func TestSendAction(t *testing.T) {
+	b, conn := newTestClient(t)
+
+	channel, _ := b.GetChannel("#osu")
+	for _, s := range []MessageSender{b.GetUser("peppy"), channel, b.GetLobby(1)} {
+		if err := s.SendAction("waves"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"PRIVMSG peppy :\x01ACTION waves\x01", "PRIVMSG #osu :\x01ACTION waves\x01", "PRIVMSG #mp_1 :\x01ACTION waves\x01"}
+	if sent := conn.Sent()[3:]; !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+}
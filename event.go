@@ -3,11 +3,15 @@ package banchogo
 import (
 	"strings"
 	"sync"
+	"time"
 )
 
 type EventEmitter struct {
 	handlersMu sync.Mutex
 	handlers   map[string][]*EventHandlerInstance
+
+	// observe is called with execution time of every handler, e.g. Client.observeHandler
+	observe func(name string, d time.Duration)
 }
 
 type EventHandler interface {
@@ -64,17 +68,27 @@ func (e *EventEmitter) emit(name string, params ...interface{}) {
 		for _, eh := range handlers {
 			if eh.once != nil {
 				eh.once.Do(func() {
-					eh.eventHandler.Call(params...)
+					e.call(name, eh, params)
 					e.off(name, eh)
 				})
 			} else {
-				eh.eventHandler.Call(params...)
+				e.call(name, eh, params)
 			}
 		}
 	}
 
 }
 
+func (e *EventEmitter) call(name string, eh *EventHandlerInstance, params []interface{}) {
+	if e.observe == nil {
+		eh.eventHandler.Call(params...)
+		return
+	}
+	start := time.Now()
+	eh.eventHandler.Call(params...)
+	e.observe(name, time.Since(start))
+}
+
 func (e *EventEmitter) Emit(name string, params ...interface{}) {
 	e.emit(strings.ToLower(name), params...)
 }
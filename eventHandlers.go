@@ -6,6 +6,50 @@ SEE event_types.go INSTEAD
 
 package banchogo
 
+func (eh BeatmapChangedHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(int)
+
+	a1, _ := a[1].(string)
+
+	eh(a0, a1)
+}
+
+func (eh BeatmapChangedHandlerType) NumField() int {
+	return 2
+}
+
+func (eh BeatmapHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*Beatmap)
+
+	eh(a0)
+}
+
+func (eh BeatmapHandlerType) NumField() int {
+	return 1
+}
+
+func (eh CTCPHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(Message)
+
+	a1, _ := a[1].(*CTCP)
+
+	eh(a0, a1)
+}
+
+func (eh CTCPHandlerType) NumField() int {
+	return 2
+}
+
+func (eh ChannelHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*Channel)
+
+	eh(a0)
+}
+
+func (eh ChannelHandlerType) NumField() int {
+	return 1
+}
+
 func (eh ChannelMemberHandlerType) Call(a ...interface{}) {
 	a0, _ := a[0].(*ChannelMember)
 
@@ -60,6 +104,38 @@ func (eh EmptyHandlerType) NumField() int {
 	return 0
 }
 
+func (eh LobbyFailoverHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*Lobby)
+
+	a1, _ := a[1].(*Lobby)
+
+	eh(a0, a1)
+}
+
+func (eh LobbyFailoverHandlerType) NumField() int {
+	return 2
+}
+
+func (eh LobbyPlayerHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*LobbyPlayer)
+
+	eh(a0)
+}
+
+func (eh LobbyPlayerHandlerType) NumField() int {
+	return 1
+}
+
+func (eh MatchResultHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*MatchResult)
+
+	eh(a0)
+}
+
+func (eh MatchResultHandlerType) NumField() int {
+	return 1
+}
+
 func (eh MessageHandlerType) Call(a ...interface{}) {
 	a0, _ := a[0].(Message)
 
@@ -70,6 +146,56 @@ func (eh MessageHandlerType) NumField() int {
 	return 1
 }
 
+func (eh ModeChangeHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*ChannelMember)
+
+	a1, _ := a[1].(ChannelMemberMode)
+
+	a2, _ := a[2].(ChannelMemberMode)
+
+	eh(a0, a1, a2)
+}
+
+func (eh ModeChangeHandlerType) NumField() int {
+	return 3
+}
+
+func (eh NowPlayingHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(Message)
+
+	a1, _ := a[1].(*NowPlaying)
+
+	eh(a0, a1)
+}
+
+func (eh NowPlayingHandlerType) NumField() int {
+	return 2
+}
+
+func (eh PoolConnectStateHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*Client)
+
+	a1, _ := a[1].(ConnectState)
+
+	eh(a0, a1)
+}
+
+func (eh PoolConnectStateHandlerType) NumField() int {
+	return 2
+}
+
+func (eh PoolMessageHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*Client)
+
+	a1, _ := a[1].(Message)
+
+	eh(a0, a1)
+}
+
+func (eh PoolMessageHandlerType) NumField() int {
+	return 2
+}
+
 func (eh PrivateMessageHandlerType) Call(a ...interface{}) {
 	a0, _ := a[0].(*PrivateMessage)
 
@@ -90,6 +216,26 @@ func (eh RawMessageHandlerType) NumField() int {
 	return 1
 }
 
+func (eh RollHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*RollResult)
+
+	eh(a0)
+}
+
+func (eh RollHandlerType) NumField() int {
+	return 1
+}
+
+func (eh UserHandlerType) Call(a ...interface{}) {
+	a0, _ := a[0].(*User)
+
+	eh(a0)
+}
+
+func (eh UserHandlerType) NumField() int {
+	return 1
+}
+
 func (eh WithErrorHandlerType) Call(a ...interface{}) {
 	a0, _ := a[0].(error)
 
@@ -102,6 +248,14 @@ func (eh WithErrorHandlerType) NumField() int {
 
 func interfaceToEventHandler(handler interface{}) EventHandler {
 	switch eh := handler.(type) {
+	case func(int, string):
+		return BeatmapChangedHandlerType(eh)
+	case func(*Beatmap):
+		return BeatmapHandlerType(eh)
+	case func(Message, *CTCP):
+		return CTCPHandlerType(eh)
+	case func(*Channel):
+		return ChannelHandlerType(eh)
 	case func(*ChannelMember):
 		return ChannelMemberHandlerType(eh)
 	case func(*ChannelMessage):
@@ -112,12 +266,30 @@ func interfaceToEventHandler(handler interface{}) EventHandler {
 		return EllipseInterfaceHandlerType(eh)
 	case func():
 		return EmptyHandlerType(eh)
+	case func(*Lobby, *Lobby):
+		return LobbyFailoverHandlerType(eh)
+	case func(*LobbyPlayer):
+		return LobbyPlayerHandlerType(eh)
+	case func(*MatchResult):
+		return MatchResultHandlerType(eh)
 	case func(Message):
 		return MessageHandlerType(eh)
+	case func(*ChannelMember, ChannelMemberMode, ChannelMemberMode):
+		return ModeChangeHandlerType(eh)
+	case func(Message, *NowPlaying):
+		return NowPlayingHandlerType(eh)
+	case func(*Client, ConnectState):
+		return PoolConnectStateHandlerType(eh)
+	case func(*Client, Message):
+		return PoolMessageHandlerType(eh)
 	case func(*PrivateMessage):
 		return PrivateMessageHandlerType(eh)
 	case func([]string):
 		return RawMessageHandlerType(eh)
+	case func(*RollResult):
+		return RollHandlerType(eh)
+	case func(*User):
+		return UserHandlerType(eh)
 	case func(error):
 		return WithErrorHandlerType(eh)
 	default:
@@ -21,3 +21,29 @@ type PrivateMessageHandlerType func(*PrivateMessage)
 type ChannelMessageHandlerType func(*ChannelMessage)
 
 type ChannelMemberHandlerType func(*ChannelMember)
+
+type UserHandlerType func(*User)
+
+type LobbyPlayerHandlerType func(*LobbyPlayer)
+
+type BeatmapChangedHandlerType func(int, string)
+
+type MatchResultHandlerType func(*MatchResult)
+
+type ChannelHandlerType func(*Channel)
+
+type BeatmapHandlerType func(*Beatmap)
+
+type NowPlayingHandlerType func(Message, *NowPlaying)
+
+type CTCPHandlerType func(Message, *CTCP)
+
+type RollHandlerType func(*RollResult)
+
+type ModeChangeHandlerType func(*ChannelMember, ChannelMemberMode, ChannelMemberMode)
+
+type PoolMessageHandlerType func(*Client, Message)
+
+type PoolConnectStateHandlerType func(*Client, ConnectState)
+
+type LobbyFailoverHandlerType func(*Lobby, *Lobby)
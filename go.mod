@@ -6,6 +6,7 @@ require (
 	github.com/puzpuzpuz/xsync/v2 v2.4.0
 	github.com/thehowl/go-osuapi v0.0.0-20181219091033-b29455689881
 	go.uber.org/ratelimit v0.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/andres-erbsen/clock v0.0.0-20160526145045-9e14626cd129 // indirect
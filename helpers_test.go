@@ -0,0 +1,56 @@
+package banchogo
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+// newReplayClient returns a client which isn't connected yet. On Connect it talks to an in-memory server
+// which replays transcript lines after the login, lines sent by the client are collected by conn.
+// Username and Password are "bot" and "secret" if not set.
+// Other packages use internal/banchotest, it can't be imported here because it imports banchogo.
+func newReplayClient(t *testing.T, opt ClientOptions, lines ...string) (*Client, *ReplayConn) {
+	t.Helper()
+	if opt.Username == "" {
+		opt.Username = "bot"
+	}
+	if opt.Password == "" {
+		opt.Password = "secret"
+	}
+
+	lines = append([]string{"0\t:cho.ppy.sh 001 " + opt.Username + " :Welcome to the osu!Bancho."}, lines...)
+	transcript, err := ReadTranscript(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := transcript.Conn(1)
+	opt.Dial = func(string, string) (net.Conn, error) { return conn, nil }
+
+	b := NewBanchoClient(opt)
+	b.RateLimiter = nil
+	return b, conn
+}
+
+// connectTestClient connects the client and disconnects it when the test finishes
+func connectTestClient(t *testing.T, b *Client) {
+	t.Helper()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Disconnect)
+}
+
+// newTestClient returns a connected "bot" client, see newReplayClient
+func newTestClient(t *testing.T, lines ...string) (*Client, *ReplayConn) {
+	t.Helper()
+	b, conn := newReplayClient(t, ClientOptions{}, lines...)
+	connectTestClient(t, b)
+	return b, conn
+}
+
+// feedLine passes a raw line to IRC handlers as if the client received it
+func feedLine(b *Client, line string) {
+	splits := strings.Split(line, " ")
+	IrcHandlers[splits[1]](b, splits)
+}
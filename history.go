@@ -0,0 +1,552 @@
+package banchogo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is how many messages are kept per channel or private conversation by default
+const DefaultHistorySize = 100
+
+// HistoryEntry is a message kept in conversation history
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Content string    `json:"content"`
+	// Self is true for messages sent by the client
+	Self bool `json:"self,omitempty"`
+}
+
+// Action returns text of a /me action, empty string if the entry isn't an action
+func (e HistoryEntry) Action() string {
+	if c := DecodeCTCP(e.Content); c != nil && c.Command == "ACTION" {
+		return c.Params
+	}
+	return ""
+}
+
+// Links returns all links in the message
+func (e HistoryEntry) Links() []ChatLink {
+	return ParseLinks(e.Content)
+}
+
+// HistoryStore persists conversation history, so it survives restarts.
+// Key is a channel name like "#osu" or a lower case username for private conversations.
+type HistoryStore interface {
+	// Append is called from the client's reader goroutine, it must not wait for slow I/O
+	Append(key string, e HistoryEntry) error
+	// Load returns up to n last entries, oldest first
+	Load(key string, n int) ([]HistoryEntry, error)
+}
+
+// History is a fixed size ring buffer of conversation messages, oldest messages are dropped first
+type History struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	start   int
+	count   int
+
+	key   string
+	store HistoryStore
+	// loaded is closed when persisted messages are loaded, unsaved messages were added before that
+	loaded  chan struct{}
+	loading bool
+	unsaved []HistoryEntry
+}
+
+// NewHistory creates a history keeping up to size messages. If store is not nil,
+// the last messages are loaded from it in background and new messages are appended to it.
+func NewHistory(size int, key string, store HistoryStore) *History {
+	h := &History{entries: make([]HistoryEntry, size), key: key, store: store, loaded: make(chan struct{})}
+	if store == nil {
+		close(h.loaded)
+		return h
+	}
+	h.loading = true
+	go h.load()
+	return h
+}
+
+// load puts persisted messages before messages received while loading, it runs outside of the reader goroutine
+func (h *History) load() {
+	// History without old messages is still useful, load errors are ignored
+	loaded, _ := h.store.Load(h.key, len(h.entries))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recent := h.last(0)
+	h.start, h.count = 0, 0
+	for _, e := range append(loaded, recent...) {
+		h.push(e)
+	}
+	// Messages are stored only after loading, otherwise the store could return them as old ones
+	for _, e := range h.unsaved {
+		_ = h.store.Append(h.key, e)
+	}
+	h.unsaved = nil
+	h.loading = false
+	close(h.loaded)
+}
+
+// Loaded is closed when persisted messages are loaded from the store
+func (h *History) Loaded() <-chan struct{} {
+	return h.loaded
+}
+
+// Add appends a message to the history and to the store
+func (h *History) Add(e HistoryEntry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.push(e)
+
+	switch {
+	case h.store == nil:
+	case h.loading:
+		h.unsaved = append(h.unsaved, e)
+	default:
+		// Appending under the lock keeps messages in order, event handlers have nowhere to return the error
+		_ = h.store.Append(h.key, e)
+	}
+}
+
+func (h *History) push(e HistoryEntry) {
+	if len(h.entries) == 0 {
+		return
+	}
+	if h.count < len(h.entries) {
+		h.entries[(h.start+h.count)%len(h.entries)] = e
+		h.count++
+		return
+	}
+	h.entries[h.start] = e
+	h.start = (h.start + 1) % len(h.entries)
+}
+
+// Len returns number of kept messages
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Last returns up to n last messages, oldest first. n less than 1 returns all kept messages.
+func (h *History) Last(n int) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.last(n)
+}
+
+func (h *History) last(n int) []HistoryEntry {
+	if n < 1 || n > h.count {
+		n = h.count
+	}
+	res := make([]HistoryEntry, n)
+	for i := range res {
+		res[i] = h.entries[(h.start+h.count-n+i)%len(h.entries)]
+	}
+	return res
+}
+
+// Between returns messages sent in [from, to) time range, zero time means no bound
+func (h *History) Between(from, to time.Time) []HistoryEntry {
+	return h.Filter(func(e HistoryEntry) bool {
+		return (from.IsZero() || !e.Time.Before(from)) && (to.IsZero() || e.Time.Before(to))
+	})
+}
+
+// Search returns messages containing the text case-insensitively, oldest first
+func (h *History) Search(text string) []HistoryEntry {
+	text = strings.ToLower(text)
+	return h.Filter(func(e HistoryEntry) bool {
+		return strings.Contains(strings.ToLower(e.Content), text)
+	})
+}
+
+// Filter returns messages matching the predicate, oldest first
+func (h *History) Filter(match func(HistoryEntry) bool) []HistoryEntry {
+	var res []HistoryEntry
+	for _, e := range h.Last(0) {
+		if match(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// newHistory creates a conversation history with client settings, nil if history is disabled
+func (b *Client) newHistory(key string) *History {
+	size := b.HistorySize
+	if size == 0 {
+		size = DefaultHistorySize
+	}
+	if size < 0 {
+		return nil
+	}
+	return NewHistory(size, key, b.HistoryStore)
+}
+
+// HistoryBuffer returns the channel history for time range queries and search, nil if history is disabled
+func (c *Channel) HistoryBuffer() *History {
+	c.historyOnce.Do(func() {
+		c.history = c.client.newHistory(strings.ToLower(c.Name()))
+	})
+	return c.history
+}
+
+// History returns up to n last messages in the channel including messages sent by the client, oldest first
+func (c *Channel) History(n int) []HistoryEntry {
+	if h := c.HistoryBuffer(); h != nil {
+		return h.Last(n)
+	}
+	return nil
+}
+
+// HistoryBuffer returns history of private messages with the user, nil if history is disabled
+func (u *User) HistoryBuffer() *History {
+	u.historyOnce.Do(func() {
+		u.history = u.client.newHistory(strings.ToLower(u.Name()))
+	})
+	return u.history
+}
+
+// History returns up to n last private messages with the user in both directions, oldest first
+func (u *User) History(n int) []HistoryEntry {
+	if h := u.HistoryBuffer(); h != nil {
+		return h.Last(n)
+	}
+	return nil
+}
+
+// recordChannelMessage must be called for incoming and outgoing channel messages
+func (b *Client) recordChannelMessage(m *ChannelMessage) {
+	if h := m.Channel.HistoryBuffer(); h != nil {
+		h.Add(HistoryEntry{User: m.User.Name(), Content: m.Content(), Self: m.Self})
+	}
+}
+
+// recordPrivateMessage must be called for incoming and outgoing private messages
+func (b *Client) recordPrivateMessage(m *PrivateMessage) {
+	peer := m.User
+	if m.Self {
+		peer = m.Recipient
+	}
+	if h := peer.HistoryBuffer(); h != nil {
+		h.Add(HistoryEntry{User: m.User.Name(), Content: m.Content(), Self: m.Self})
+	}
+}
+
+const (
+	// maxOpenHistoryFiles limits file handles kept open by FileHistoryStore
+	maxOpenHistoryFiles = 64
+	// historyCompactRatio is how many times a file may be larger than the loaded entries before it's compacted
+	historyCompactRatio = 4
+	// historyReadChunk is how many bytes are read at once when the file is read from the end
+	historyReadChunk = 64 * 1024
+)
+
+var ErrHistoryStoreClosed = errors.New("history store is closed")
+
+// FileHistoryStore keeps history of every conversation in a separate JSON lines file.
+// Entries are written by a background goroutine with files kept open, so Append never waits for the disk.
+// Files are read from the end and compacted to twice the loaded size when they grow larger.
+type FileHistoryStore struct {
+	Dir string
+
+	startOnce sync.Once
+	mu        sync.Mutex
+	queue     []historyRecord
+	wake      chan struct{}
+	closed    bool
+	err       error
+
+	// files are used only by the writer goroutine
+	files map[string]*historyFile
+}
+
+// historyRecord is an entry to write, a flush request if flushed isn't nil
+// or a request to keep only last entries of the file if keep isn't 0
+type historyRecord struct {
+	key     string
+	data    []byte
+	flushed chan struct{}
+	keep    int
+}
+
+type historyFile struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+func (f *FileHistoryStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".jsonl")
+}
+
+func (f *FileHistoryStore) start() {
+	f.startOnce.Do(func() {
+		f.wake = make(chan struct{}, 1)
+		f.files = make(map[string]*historyFile)
+		go f.run()
+	})
+}
+
+// enqueue adds a record for the writer goroutine, ok is false if the store is closed
+func (f *FileHistoryStore) enqueue(r historyRecord) (ok bool) {
+	f.start()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return false
+	}
+	f.queue = append(f.queue, r)
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Append queues the entry, write errors are reported by Err
+func (f *FileHistoryStore) Append(key string, e HistoryEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if !f.enqueue(historyRecord{key: key, data: append(data, '\n')}) {
+		return ErrHistoryStoreClosed
+	}
+	return nil
+}
+
+// Flush waits until all appended entries are written
+func (f *FileHistoryStore) Flush() error {
+	flushed := make(chan struct{})
+	if f.enqueue(historyRecord{flushed: flushed}) {
+		<-flushed
+	}
+	return f.Err()
+}
+
+// Close writes appended entries and closes files, the store can't be used after it
+func (f *FileHistoryStore) Close() error {
+	err := f.Flush()
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.wake)
+	}
+	f.mu.Unlock()
+	return err
+}
+
+// Err returns the first write error
+func (f *FileHistoryStore) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *FileHistoryStore) run() {
+	for range f.wake {
+		f.mu.Lock()
+		queue := f.queue
+		f.queue = nil
+		f.mu.Unlock()
+
+		// Entries are buffered and every batch is flushed at once
+		for _, r := range queue {
+			switch {
+			case r.keep > 0:
+				f.compact(r.key, r.keep)
+			case r.flushed == nil:
+				f.write(r)
+			}
+		}
+		f.flushFiles()
+		for _, r := range queue {
+			if r.flushed != nil {
+				close(r.flushed)
+			}
+		}
+	}
+
+	f.closeFiles()
+}
+
+func (f *FileHistoryStore) write(r historyRecord) {
+	file, ok := f.files[r.key]
+	if !ok {
+		if len(f.files) >= maxOpenHistoryFiles {
+			f.closeFiles()
+		}
+		if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+			f.setErr(err)
+			return
+		}
+		fd, err := os.OpenFile(f.path(r.key), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			f.setErr(err)
+			return
+		}
+		file = &historyFile{f: fd, w: bufio.NewWriter(fd)}
+		f.files[r.key] = file
+	}
+	_, err := file.w.Write(r.data)
+	f.setErr(err)
+}
+
+func (f *FileHistoryStore) flushFiles() {
+	for _, file := range f.files {
+		f.setErr(file.w.Flush())
+	}
+}
+
+// closeFiles closes every open file, conversations are reopened on the next message
+func (f *FileHistoryStore) closeFiles() {
+	for key, file := range f.files {
+		f.setErr(file.w.Flush())
+		f.setErr(file.f.Close())
+		delete(f.files, key)
+	}
+}
+
+func (f *FileHistoryStore) setErr(err error) {
+	if err == nil {
+		return
+	}
+	f.mu.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.mu.Unlock()
+}
+
+// Load returns up to n last entries, entries appended before the call are included
+func (f *FileHistoryStore) Load(key string, n int) ([]HistoryEntry, error) {
+	// Pending entries are written first, a closed store has nothing pending
+	f.Flush()
+
+	file, err := os.Open(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, start, size, err := readHistoryTail(file, n)
+	if err == nil && n > 0 && size > historyCompactRatio*(size-start) {
+		f.enqueue(historyRecord{key: key, keep: 2 * n})
+	}
+	return entries, err
+}
+
+// compact rewrites the file with only keep last entries, it's called by the writer goroutine
+func (f *FileHistoryStore) compact(key string, keep int) {
+	if file, ok := f.files[key]; ok {
+		f.setErr(file.w.Flush())
+		f.setErr(file.f.Close())
+		delete(f.files, key)
+	}
+
+	path := f.path(key)
+	file, err := os.Open(path)
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+	defer file.Close()
+	entries, start, _, err := readHistoryTail(file, keep)
+	if err != nil || start == 0 {
+		f.setErr(err)
+		return
+	}
+
+	// Entries are encoded again, so a line cut by a crash isn't kept.
+	// They are written to a temporary file first, so a crash doesn't lose the history.
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			f.setErr(err)
+			return
+		}
+		buf.Write(append(data, '\n'))
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, buf.Bytes(), 0o644); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	f.setErr(err)
+}
+
+// readHistoryTail reads up to n last entries of the file from its end, n < 1 reads all of them.
+// start is the offset of the first returned entry, size is the size of the file.
+func readHistoryTail(file *os.File, n int) (entries []HistoryEntry, start, size int64, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	size = info.Size()
+
+	// buf holds the file from base to its end
+	var buf []byte
+	base := size
+	for {
+		var offset int
+		entries, offset = parseHistoryTail(buf, base == 0, n)
+		if (n > 0 && len(entries) >= n) || base == 0 {
+			return entries, base + int64(offset), size, nil
+		}
+
+		read := int64(historyReadChunk)
+		if read > base {
+			read = base
+		}
+		chunk := make([]byte, read, read+int64(len(buf)))
+		if _, err = file.ReadAt(chunk, base-read); err != nil && err != io.EOF {
+			return nil, 0, size, err
+		}
+		buf = append(chunk, buf...)
+		base -= read
+	}
+}
+
+// parseHistoryTail parses up to n last lines of buf, n < 1 parses all of them. The first line is skipped
+// unless whole is set, it may be cut. offset is where the line of the first returned entry starts.
+func parseHistoryTail(buf []byte, whole bool, n int) (entries []HistoryEntry, offset int) {
+	offset = len(buf)
+	end := len(buf)
+	for end > 0 && (n < 1 || len(entries) < n) {
+		lineStart := bytes.LastIndexByte(buf[:end], '\n') + 1
+		if lineStart == 0 && !whole {
+			break
+		}
+		var e HistoryEntry
+		// A line may be cut if the process was killed while writing
+		if line := buf[lineStart:end]; len(line) > 0 && json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+			offset = lineStart
+		}
+		end = lineStart - 1
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, offset
+}
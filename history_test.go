@@ -0,0 +1,146 @@
+package banchogo
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3, "#osu", nil)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, text := range []string{"one", "two", "three", "four"} {
+		h.Add(HistoryEntry{Time: start.Add(time.Duration(i) * time.Minute), User: "peppy", Content: text})
+	}
+
+	contents := func(entries []HistoryEntry) []string {
+		var res []string
+		for _, e := range entries {
+			res = append(res, e.Content)
+		}
+		return res
+	}
+
+	if got := contents(h.Last(0)); !reflect.DeepEqual(got, []string{"two", "three", "four"}) {
+		t.Errorf("oldest message should be dropped, got %q", got)
+	}
+	if got := contents(h.Last(2)); !reflect.DeepEqual(got, []string{"three", "four"}) {
+		t.Errorf("unexpected last messages %q", got)
+	}
+	if got := contents(h.Between(start.Add(time.Minute), start.Add(3*time.Minute))); !reflect.DeepEqual(got, []string{"two", "three"}) {
+		t.Errorf("unexpected messages in range %q", got)
+	}
+	if got := contents(h.Search("T")); !reflect.DeepEqual(got, []string{"two", "three"}) {
+		t.Errorf("unexpected search result %q", got)
+	}
+}
+
+func TestClient_History(t *testing.T) {
+	store := &FileHistoryStore{Dir: t.TempDir()}
+	b := NewBanchoClient(ClientOptions{Username: "bot", HistorySize: 2, HistoryStore: store})
+
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :pick https://osu.ppy.sh/b/75")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG bot :hi bot")
+	feedLine(b, ":Some_Player!cho@ppy.sh PRIVMSG #osu :\x01ACTION waves\x01")
+
+	osu, _ := b.GetChannel("#osu")
+	h := osu.History(10)
+	if len(h) != 2 || h[0].User != "peppy" || h[1].Action() != "waves" {
+		t.Fatalf("unexpected channel history %+v", h)
+	}
+	if ref, ok := h[0].Links()[0].Ref.(BeatmapRef); !ok || ref.ID != 75 {
+		t.Errorf("unexpected link in history %+v", h[0].Links())
+	}
+	if h := b.GetUser("peppy").History(10); len(h) != 1 || h[0].Content != "hi bot" || h[0].Self {
+		t.Errorf("unexpected private history %+v", h)
+	}
+
+	// Messages are stored after the history is loaded, a new client loads them in background
+	<-osu.HistoryBuffer().Loaded()
+	b2 := NewBanchoClient(ClientOptions{Username: "bot", HistorySize: 1, HistoryStore: store})
+	osu2, _ := b2.GetChannel("#osu")
+	<-osu2.HistoryBuffer().Loaded()
+	if h := osu2.History(10); len(h) != 1 || h[0].User != "Some_Player" {
+		t.Errorf("unexpected persisted history %+v", h)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := store.Append("#osu", HistoryEntry{}); err != ErrHistoryStoreClosed {
+		t.Errorf("expected ErrHistoryStoreClosed after Close, got %v", err)
+	}
+
+	if h := NewBanchoClient(ClientOptions{HistorySize: -1}).GetUser("peppy").History(10); h != nil {
+		t.Errorf("history should be disabled, got %+v", h)
+	}
+}
+
+func TestFileHistoryStore_Compact(t *testing.T) {
+	store := &FileHistoryStore{Dir: t.TempDir()}
+	defer store.Close()
+	for i := 0; i < 20; i++ {
+		store.Append("#osu", HistoryEntry{Content: strconv.Itoa(i)})
+	}
+	store.Flush()
+	// A line cut by a crash is skipped
+	f, err := os.OpenFile(store.path("#osu"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"content":"cut`)
+	f.Close()
+
+	contents := func(entries []HistoryEntry) (res []string) {
+		for _, e := range entries {
+			res = append(res, e.Content)
+		}
+		return res
+	}
+	entries, err := store.Load("#osu", 2)
+	if err != nil || !reflect.DeepEqual(contents(entries), []string{"18", "19"}) {
+		t.Fatalf("unexpected loaded entries %q (%v)", contents(entries), err)
+	}
+
+	// The file is much larger than the loaded entries, so it's compacted to twice their size
+	store.Flush()
+	store.Append("#osu", HistoryEntry{Content: "20"})
+	entries, err = store.Load("#osu", 0)
+	if err != nil || !reflect.DeepEqual(contents(entries), []string{"16", "17", "18", "19", "20"}) {
+		t.Errorf("unexpected entries after compaction %q (%v)", contents(entries), err)
+	}
+}
+
+// slowHistoryStore blocks Load until release is closed
+type slowHistoryStore struct {
+	release  chan struct{}
+	appended []string
+}
+
+func (s *slowHistoryStore) Append(_ string, e HistoryEntry) error {
+	s.appended = append(s.appended, e.Content)
+	return nil
+}
+
+func (s *slowHistoryStore) Load(string, int) ([]HistoryEntry, error) {
+	<-s.release
+	return []HistoryEntry{{Content: "old"}}, nil
+}
+
+func TestHistory_LoadInBackground(t *testing.T) {
+	store := &slowHistoryStore{release: make(chan struct{})}
+	h := NewHistory(10, "#osu", store)
+	// Adding doesn't wait for the store
+	h.Add(HistoryEntry{Content: "new"})
+
+	close(store.release)
+	<-h.Loaded()
+	if got := h.Last(0); len(got) != 2 || got[0].Content != "old" || got[1].Content != "new" {
+		t.Errorf("loaded messages should go before new ones, got %+v", got)
+	}
+	if !reflect.DeepEqual(store.appended, []string{"new"}) {
+		t.Errorf("new message should be stored after loading, got %q", store.appended)
+	}
+}
@@ -0,0 +1,55 @@
+// Package banchotest provides a client fixture for tests of packages built on banchogo
+package banchotest
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+// NewClient returns a client connected to an in-memory server which replays transcript lines after the login,
+// lines sent by the client are collected by conn. The client is disconnected when the test finishes.
+// Username and Password are "bot" and "secret" if not set, messages aren't rate limited unless
+// opt.RateLimiter is set.
+func NewClient(t testing.TB, opt banchogo.ClientOptions, lines ...string) (*banchogo.Client, *banchogo.ReplayConn) {
+	t.Helper()
+	if opt.Username == "" {
+		opt.Username = "bot"
+	}
+	if opt.Password == "" {
+		opt.Password = "secret"
+	}
+
+	lines = append([]string{"0\t:cho.ppy.sh 001 " + opt.Username + " :Welcome to the osu!Bancho."}, lines...)
+	transcript, err := banchogo.ReadTranscript(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := transcript.Conn(1)
+	opt.Dial = func(string, string) (net.Conn, error) { return conn, nil }
+
+	b := banchogo.NewBanchoClient(opt)
+	// NewBanchoClient only creates a default limiter
+	b.RateLimiter = opt.RateLimiter
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Disconnect)
+	return b, conn
+}
+
+// WaitSent waits until the client sends n lines after the login and returns them
+func WaitSent(t testing.TB, conn *banchogo.ReplayConn, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sent := conn.Sent()[3:]
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,70 @@
+// Package outbox runs lobby actions in order outside of event handlers
+package outbox
+
+import "sync"
+
+// Outbox runs queued actions one by one in its own goroutine. Event handlers run on the client's
+// reader goroutine and sending a message waits for the rate limiter, so handlers queue messages here.
+// The queue is unbounded, Send never blocks.
+type Outbox struct {
+	mu      sync.Mutex
+	actions []func() error
+	closed  bool
+	wake    chan struct{}
+}
+
+// New creates an outbox and starts its goroutine, it runs until Close
+func New() *Outbox {
+	o := &Outbox{wake: make(chan struct{}, 1)}
+	go o.run()
+	return o
+}
+
+// Send queues an action, it does nothing if the outbox is nil or closed
+func (o *Outbox) Send(action func() error) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.actions = append(o.actions, action)
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the outbox after already queued actions are run
+func (o *Outbox) Close() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.closed = true
+		close(o.wake)
+	}
+}
+
+func (o *Outbox) run() {
+	for range o.wake {
+		for {
+			o.mu.Lock()
+			if len(o.actions) == 0 {
+				o.mu.Unlock()
+				break
+			}
+			action := o.actions[0]
+			o.actions[0] = nil
+			o.actions = o.actions[1:]
+			o.mu.Unlock()
+
+			// Errors are ignored, referee can always repeat a command manually
+			_ = action()
+		}
+	}
+}
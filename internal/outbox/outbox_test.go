@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutbox_Order(t *testing.T) {
+	o := New()
+	release := make(chan struct{})
+	done := make(chan []int, 1)
+	var got []int
+
+	o.Send(func() error { <-release; return nil })
+	// The queue is unbounded, so a stuck action doesn't block senders
+	for i := 0; i < 1000; i++ {
+		i := i
+		o.Send(func() error { got = append(got, i); return nil })
+	}
+	o.Send(func() error { done <- got; return nil })
+	o.Close()
+	o.Send(func() error { t.Error("action sent after Close was run"); return nil })
+	close(release)
+
+	select {
+	case got := <-done:
+		for i, v := range got {
+			if v != i {
+				t.Fatalf("actions run out of order: %v", got)
+			}
+		}
+		if len(got) != 1000 {
+			t.Fatalf("expected 1000 actions, got %d", len(got))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued actions weren't run after Close")
+	}
+}
+
+func TestOutbox_Nil(t *testing.T) {
+	var o *Outbox
+	o.Send(func() error { t.Error("nil outbox ran an action"); return nil })
+	o.Close()
+}
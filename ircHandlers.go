@@ -5,7 +5,6 @@ import (
 )
 
 var ignoredCodes = []string{
-	"312",
 	"333",
 	"366",
 	"372",
@@ -16,10 +15,17 @@ var ignoredCodes = []string{
 var IrcHandlers = map[string]func(*Client, []string){
 	"001":     handleWelcomeCommand,
 	"311":     handleWhoisUserCommand,
-	"319":     handleWhoisChannelsCommand,
+	"312":     handleWhoisServerCommand,
+	"313":     handleWhoisOperatorCommand,
+	"317":     handleWhoisIdleCommand,
 	"318":     handleWhoisEndCommand,
+	"319":     handleWhoisChannelsCommand,
+	"321":     handleListStartCommand,
+	"322":     handleListCommand,
+	"323":     handleListEndCommand,
 	"332":     handleChannelTopicCommand,
 	"353":     handleNamesCommand,
+	"401":     handleNoSuchNickCommand,
 	"403":     handleChannelNotFoundCommand,
 	"464":     handleBadAuthCommand,
 	"PRIVMSG": handlePrivmsgCommand,
@@ -44,15 +50,36 @@ func handlePrivmsgCommand(b *Client, splits []string) {
 
 	if strings.ToLower(splits[2]) == strings.ToLower(b.Username) {
 		pm := newPrivateMessage(b, username, b.GetSelf(), false, content)
+		b.recordPrivateMessage(pm)
 		b.ev.Emit("PrivateMessage", pm)
 		b.ev.Emit("Message", Message(pm))
+		emitCTCP(b, pm)
+		emitNowPlaying(b, pm)
+		b.handleCTCPQuery(pm)
+		b.trackRoll(nil, username, content)
 	} else if strings.Index(splits[2], "#") == -1 {
 		b.ev.Emit("RejectedMessage", newPrivateMessage(b, username, b.GetSelf(), true, content))
 	} else {
 		channel, _ := b.GetChannel(splits[2])
-		cm := newChannelMessage(b, username, channel, true, content)
+		cm := newChannelMessage(b, username, channel, username.IsClient(), content)
+		b.recordChannelMessage(cm)
 		b.ev.Emit("ChannelMessage", cm)
 		b.ev.Emit("Message", Message(cm))
+		emitCTCP(b, cm)
+		emitNowPlaying(b, cm)
+		b.trackRoll(channel, username, content)
+	}
+}
+
+func emitCTCP(b *Client, m Message) {
+	if c := m.CTCP(); c != nil {
+		b.ev.Emit("CTCP", m, c)
+	}
+}
+
+func emitNowPlaying(b *Client, m Message) {
+	if np := m.NowPlaying(); np != nil {
+		b.ev.Emit("NowPlaying", m, np)
 	}
 }
 
@@ -91,27 +118,70 @@ func handleQuitCommand(b *Client, splits []string) {
 	})
 }
 
+// :BanchoBot!cho@ppy.sh MODE #osu +o peppy
 func handleModeCommand(b *Client, splits []string) {
-	channel, _ := b.GetChannel(splits[2])
-	mode := ChannelMemberMode(splits[3][1:2])
-	user := b.GetUser(splits[4])
+	if len(splits) < 5 {
+		return
+	}
+	channel, err := b.GetChannel(splits[2])
+	if err != nil {
+		return
+	}
 
-	channel.Members.Compute(user.Name(), func(oldV *ChannelMember, loaded bool) (newV *ChannelMember, delete bool) {
+	// Several modes can be changed at once, e.g. "+o-v peppy BanchoBot"
+	args := splits[4:]
+	adding := true
+	for _, m := range splits[3] {
+		switch m {
+		case '+':
+			adding = true
+		case '-':
+			adding = false
+		case 'o', 'v':
+			if len(args) == 0 {
+				return
+			}
+			setMemberMode(b, channel, b.GetUser(args[0]), ChannelMemberMode(m), adding)
+			args = args[1:]
+		}
+	}
+}
+
+// setMemberMode applies a mode change, Bancho members have only one mode and operator takes precedence over voice
+func setMemberMode(b *Client, channel *Channel, user *User, mode ChannelMemberMode, adding bool) {
+	var (
+		member           *ChannelMember
+		oldMode, newMode ChannelMemberMode
+	)
+	channel.Members.Compute(user.Name(), func(m *ChannelMember, loaded bool) (*ChannelMember, bool) {
 		if !loaded {
-			newV = newChannelMember(b, channel, splits[4])
-		} else {
-			oldV.Mode = mode
-			newV = oldV
+			// Removing a mode from an unknown user mustn't add them to the channel
+			if !adding {
+				return m, true
+			}
+			m = &ChannelMember{Channel: channel, User: user}
 		}
-		return
+		oldMode = m.Mode
+		switch {
+		case adding && (mode == IRCModerator || m.Mode == ""):
+			m.Mode = mode
+		case !adding && m.Mode == mode:
+			m.Mode = ""
+		}
+		member, newMode = m, m.Mode
+		return m, false
 	})
+
+	if newMode != oldMode {
+		b.ev.Emit("ModeChange", member, oldMode, newMode)
+	}
 }
 
 func handleChannelTopicCommand(b *Client, splits []string) {
 	topicSplits := splits[4:]
 	topicSplits[0] = topicSplits[0][1:]
 
-	b.Channels.Compute(splits[3], func(c *Channel, loaded bool) (nc *Channel, delete bool) {
+	channel, _ := b.Channels.Compute(splits[3], func(c *Channel, loaded bool) (nc *Channel, delete bool) {
 		if !loaded {
 			nc = NewChannel(b, splits[3])
 		} else {
@@ -120,16 +190,24 @@ func handleChannelTopicCommand(b *Client, splits []string) {
 		nc.Topic = strings.Join(topicSplits, " ")
 		return
 	})
+	b.ev.Emit("Topic", channel)
 }
 
+// :cho.ppy.sh 353 bot = #osu :@peppy +voiced regular
 func handleNamesCommand(b *Client, splits []string) {
-	var names []string
-	channel, _ := b.GetChannel(splits[4])
-
-	names = splits[4:]
-	names[0] = names[0][1:]
+	if len(splits) < 6 {
+		return
+	}
+	channel, err := b.GetChannel(splits[4])
+	if err != nil {
+		return
+	}
 
-	for _, n := range names {
+	for _, n := range splits[5:] {
+		n = strings.TrimPrefix(n, ":")
+		if n == "" {
+			continue
+		}
 		member := newChannelMember(b, channel, n)
 		channel.Members.Store(member.User.Name(), member)
 	}
@@ -151,7 +229,7 @@ func handleChannelNotFoundCommand(b *Client, splits []string) {
 
 func emitPart(b *Client, u *User, c *Channel) {
 	if member, ok := c.Members.Load(u.Name()); ok {
-		b.ev.Emit("PART", member)
+		b.ev.Emit("Part", member)
 	}
 
 	if u.IsClient() {
@@ -162,5 +240,5 @@ func emitPart(b *Client, u *User, c *Channel) {
 		}
 	}
 
-	b.Channels.Delete(u.Name())
+	c.Members.Delete(u.Name())
 }
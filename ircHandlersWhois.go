@@ -3,56 +3,201 @@ package banchogo
 import (
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 var (
 	osuLinkRegex = regexp.MustCompile(`^https?://osu\.ppy\.sh/u/(\d+)$`)
 )
 
-func handleWhoisUserCommand(b *Client, splits []string) {
-	user := b.GetUser(splits[3])
-	userId, _ := strconv.Atoi(osuLinkRegex.FindStringSubmatch(splits[4])[1])
+type WhoisResponse struct {
+	UserId     int
+	Username   string
+	ProfileURL string
+	Channels   []*Channel
+	// Server and ServerInfo come from 312 reply, e.g. "cho.ppy.sh" and "osu!Bancho"
+	Server     string
+	ServerInfo string
+	// Operator is true for IRC operators, e.g. GMT and NAT members
+	Operator bool
+	Idle     time.Duration
+	SignOn   time.Time
+	Error    error
+}
+
+// whoisRequest collects replies for a single WHOIS, concurrent callers asking about the same user share it
+type whoisRequest struct {
+	response WhoisResponse
+	waiters  []chan WhoisResponse
+	timer    *time.Timer
+}
+
+// Whois asks the server about the user. Requests for different users can run concurrently,
+// since every reply names the user it belongs to. Returns ErrUserOffline if the user is offline.
+func (u *User) Whois() <-chan WhoisResponse {
+	b := u.client
+	resp := make(chan WhoisResponse, 1)
+	name := u.Name()
+	key := strings.ToLower(name)
+
+	b.whoisMu.Lock()
+	if b.whoisRequests == nil {
+		b.whoisRequests = make(map[string]*whoisRequest)
+	}
+	req, pending := b.whoisRequests[key]
+	if !pending {
+		req = &whoisRequest{}
+		b.whoisRequests[key] = req
+		req.timer = time.AfterFunc(10*time.Second, func() {
+			b.finishWhois(key, req, WhoisResponse{Error: ErrMessageTimeout})
+		})
+	}
+	req.waiters = append(req.waiters, resp)
+	b.whoisMu.Unlock()
+
+	if pending {
+		return resp
+	}
+
+	if err := b.Send("WHOIS " + name); err != nil {
+		b.finishWhois(key, req, WhoisResponse{Error: err})
+	}
+
+	return resp
+}
 
-	select {
-	case whoisObj := <-user.whoisChan:
-		whoisObj.UserId = userId
-		b.currentWhois = whoisObj
-	default:
+// updateWhois applies a reply to the pending request for the nick, replies nobody asked for are ignored
+func (b *Client) updateWhois(nick string, update func(r *WhoisResponse)) {
+	b.whoisMu.Lock()
+	defer b.whoisMu.Unlock()
+	if req, ok := b.whoisRequests[strings.ToLower(nick)]; ok {
+		update(&req.response)
 	}
 }
 
-func handleWhoisChannelsCommand(b *Client, splits []string) {
-	user := b.GetUser(splits[3])
-	channelSplits := splits[4 : len(splits)-1]
-	channelSplits[0] = channelSplits[0][1:len(channelSplits[0])]
+// finishWhois sends the response to every caller, does nothing if the request is already finished
+func (b *Client) finishWhois(key string, req *whoisRequest, r WhoisResponse) {
+	b.whoisMu.Lock()
+	if b.whoisRequests[key] != req {
+		b.whoisMu.Unlock()
+		return
+	}
+	delete(b.whoisRequests, key)
+	req.timer.Stop()
+	b.whoisMu.Unlock()
 
-	channels := make([]*Channel, len(channelSplits), cap(channelSplits))
+	for _, w := range req.waiters {
+		resp := r
+		resp.Channels = append([]*Channel(nil), r.Channels...)
+		w <- resp
+	}
+}
+
+// finishWhoisFor finishes the pending request for the nick with collected replies or with an error
+func (b *Client) finishWhoisFor(nick string, err error) {
+	key := strings.ToLower(nick)
+
+	b.whoisMu.Lock()
+	req, ok := b.whoisRequests[key]
+	var r WhoisResponse
+	if ok {
+		r = req.response
+	}
+	b.whoisMu.Unlock()
+	if !ok {
+		return
+	}
 
-	for i, name := range channelSplits {
-		channel, err := b.GetChannel(name)
-		if err == nil {
-			channels[i] = channel
+	if err != nil {
+		r = WhoisResponse{Error: err}
+	}
+	b.finishWhois(key, req, r)
+}
+
+// :cho.ppy.sh 311 bot peppy https://osu.ppy.sh/u/2 * :peppy
+func handleWhoisUserCommand(b *Client, splits []string) {
+	if len(splits) < 5 {
+		return
+	}
+	b.updateWhois(splits[3], func(r *WhoisResponse) {
+		r.Username = splits[3]
+		for _, s := range splits[4:] {
+			if m := osuLinkRegex.FindStringSubmatch(s); m != nil {
+				r.ProfileURL = s
+				r.UserId, _ = strconv.Atoi(m[1])
+				break
+			}
 		}
+	})
+}
+
+// :cho.ppy.sh 312 bot peppy cho.ppy.sh :osu!Bancho
+func handleWhoisServerCommand(b *Client, splits []string) {
+	if len(splits) < 5 {
+		return
 	}
+	b.updateWhois(splits[3], func(r *WhoisResponse) {
+		r.Server = splits[4]
+		r.ServerInfo = strings.TrimPrefix(strings.Join(splits[5:], " "), ":")
+	})
+}
+
+// :cho.ppy.sh 313 bot peppy :is an IRC operator
+func handleWhoisOperatorCommand(b *Client, splits []string) {
+	if len(splits) < 4 {
+		return
+	}
+	b.updateWhois(splits[3], func(r *WhoisResponse) {
+		r.Operator = true
+	})
+}
 
-	if b.currentWhois != nil {
-		if b.currentWhois.user != user {
-			// TODO: For tests only, will delete later
-			panic("Whois implementation is wrong, dumbass")
+// :cho.ppy.sh 317 bot peppy 120 1600000000 :seconds idle, signon time
+func handleWhoisIdleCommand(b *Client, splits []string) {
+	if len(splits) < 5 {
+		return
+	}
+	b.updateWhois(splits[3], func(r *WhoisResponse) {
+		if idle, err := strconv.Atoi(splits[4]); err == nil {
+			r.Idle = time.Duration(idle) * time.Second
+		}
+		if len(splits) > 5 {
+			if signOn, err := strconv.ParseInt(splits[5], 10, 64); err == nil {
+				r.SignOn = time.Unix(signOn, 0)
+			}
 		}
-		b.currentWhois.Channels = append(b.currentWhois.Channels, channels...)
+	})
+}
+
+// :cho.ppy.sh 319 bot peppy :#osu @#lobby
+func handleWhoisChannelsCommand(b *Client, splits []string) {
+	if len(splits) < 5 {
+		return
 	}
+
+	b.updateWhois(splits[3], func(r *WhoisResponse) {
+		for _, name := range splits[4:] {
+			name = strings.TrimLeft(strings.TrimPrefix(name, ":"), "@+")
+			if channel, err := b.GetChannel(name); err == nil {
+				r.Channels = append(r.Channels, channel)
+			}
+		}
+	})
 }
 
+// :cho.ppy.sh 318 bot peppy :End of /WHOIS list.
 func handleWhoisEndCommand(b *Client, splits []string) {
-	user := b.GetUser(splits[3])
+	if len(splits) < 4 {
+		return
+	}
+	b.finishWhoisFor(splits[3], nil)
+}
 
-	if b.currentWhois != nil {
-		if b.currentWhois.user != user {
-			// TODO: For tests only, will delete later
-			panic("Whois implementation is wrong, dumbass")
-		}
-		close(b.currentWhois.c)
-		b.currentWhois = nil
+// :cho.ppy.sh 401 bot peppy :No such nick/channel
+func handleNoSuchNickCommand(b *Client, splits []string) {
+	if len(splits) < 4 {
+		return
 	}
+	b.finishWhoisFor(splits[3], ErrUserOffline)
 }
@@ -0,0 +1,59 @@
+package banchogo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUser_Whois(t *testing.T) {
+	// Replies for different users interleave
+	b, conn := newTestClient(t,
+		"100\t:cho.ppy.sh 311 bot peppy https://osu.ppy.sh/u/2 * :https://osu.ppy.sh/u/2",
+		":cho.ppy.sh 311 bot Some_Player https://osu.ppy.sh/u/123 * :https://osu.ppy.sh/u/123",
+		":cho.ppy.sh 401 bot Offline :No such nick/channel",
+		":cho.ppy.sh 319 bot peppy :#osu @#announce ",
+		":cho.ppy.sh 312 bot peppy cho.ppy.sh :osu!Bancho",
+		":cho.ppy.sh 313 bot peppy :is an IRC Operator",
+		":cho.ppy.sh 318 bot Some_Player :End of /WHOIS list.",
+		":cho.ppy.sh 317 bot peppy 120 1600000000 :seconds idle, signon time",
+		":cho.ppy.sh 318 bot peppy :End of /WHOIS list.")
+
+	peppy := b.GetUser("peppy").Whois()
+	// Concurrent callers asking about the same user share the request
+	peppy2 := b.GetUser("peppy").Whois()
+	player := b.GetUser("Some_Player").Whois()
+	offline := b.GetUser("Offline").Whois()
+
+	wait := func(c <-chan WhoisResponse) WhoisResponse {
+		select {
+		case r := <-c:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("whois wasn't answered")
+		}
+		return WhoisResponse{}
+	}
+
+	r := wait(peppy)
+	if r.Error != nil || r.UserId != 2 || r.ProfileURL != "https://osu.ppy.sh/u/2" || r.Server != "cho.ppy.sh" ||
+		r.ServerInfo != "osu!Bancho" || !r.Operator || r.Idle != 2*time.Minute || r.SignOn.Unix() != 1600000000 {
+		t.Errorf("unexpected response %+v", r)
+	}
+	if len(r.Channels) != 2 || r.Channels[0].Name() != "#osu" || r.Channels[1].Name() != "#announce" {
+		t.Errorf("unexpected channels %v", r.Channels)
+	}
+	if r2 := wait(peppy2); r2.UserId != 2 || len(r2.Channels) != 2 {
+		t.Errorf("unexpected shared response %+v", r2)
+	}
+
+	if r := wait(player); r.Error != nil || r.UserId != 123 || r.Username != "Some_Player" || len(r.Channels) != 0 {
+		t.Errorf("unexpected response %+v", r)
+	}
+	if r := wait(offline); r.Error != ErrUserOffline {
+		t.Errorf("expected ErrUserOffline, got %v", r.Error)
+	}
+
+	if sent := conn.Sent()[3:]; len(sent) != 3 {
+		t.Errorf("expected 3 WHOIS requests, got %q", sent)
+	}
+}
@@ -1,56 +1,140 @@
 package banchogo
 
 import (
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/thehowl/go-osuapi"
 )
 
+const maxLobbySize = 16
+
+var lobbyCreatedRegex = regexp.MustCompile(`^Created the tournament match https://osu\.ppy\.sh/mp/(\d+) (.+)$`)
+
+type CreateLobbyResponse struct {
+	Lobby *Lobby
+	Error error
+}
+
 // Lobby a bancho multiplayer lobby
 type Lobby struct {
 	mu     sync.Mutex
+	ev     *EventEmitter
 	Client *Client
 
 	Id      int
 	Channel *Channel
 
-	name      string
-	beatmapId int
-	beatmap   string
-	//teamMode
-	//winCondition
-	//mods
-	freemod bool
-	playing bool
-	//slots [16]
-	size int
+	name         string
+	beatmapId    int
+	beatmap      string
+	beatmapInfo  *Beatmap
+	mode         osuapi.Mode
+	teamMode     TeamMode
+	winCondition WinCondition
+	mods         osuapi.Mods
+	freemod      bool
+	playing      bool
+	size         int
+	closed       bool
+
+	slots    [maxLobbySize]*LobbyPlayer
+	referees map[string]struct{}
+
+	startedAt time.Time
+	scores    []MatchScore
+
+	settings *lobbySettings
 
-	//players []
+	handlerRemovers []func()
+}
 
+// lobbySettings collects "!mp settings" response which is spread across several messages
+type lobbySettings struct {
+	expectedPlayers int
+	players         []*LobbyPlayer
 }
 
+// NewLobby creates a lobby for a multiplayer channel. Use Client.GetLobby instead,
+// since lobby listens channel messages and there should be only one lobby object per channel.
 func NewLobby(c *Channel) (l *Lobby) {
 	l = &Lobby{
+		ev:      &EventEmitter{observe: c.client.observeHandler},
 		Client:  c.client,
 		Channel: c,
 
-		size: 16,
+		size:     maxLobbySize,
+		referees: make(map[string]struct{}),
+	}
+	l.Id, _ = strconv.Atoi(strings.TrimPrefix(c.Name(), "#mp_"))
+
+	l.handlerRemovers = []func(){
+		l.Channel.OnMessage(func(m *ChannelMessage) {
+			if strings.ToLower(m.User.Name()) == "banchobot" {
+				l.handleBanchoBotMessage(m.Content())
+			}
+		}),
 	}
-	l.Id, _ = strconv.Atoi(c.Name()[4:len(c.Name())])
 
-	l.Channel.OnJoin(func(m *ChannelMember) {
-		if m.User.IsClient() {
-			// TODO:
+	return
+}
+
+// GetLobby returns a lobby object for multiplayer match id
+func (b *Client) GetLobby(id int) *Lobby {
+	name := "#mp_" + strconv.Itoa(id)
+	lobby, _ := b.Lobbies.LoadOrCompute(name, func() *Lobby {
+		channel, _ := b.GetChannel(name)
+		return NewLobby(channel)
+	})
+	return lobby
+}
+
+// CreateLobby makes a new tournament lobby with "!mp make" command.
+// Bancho joins the client to the lobby channel automatically.
+func (b *Client) CreateLobby(name string) <-chan CreateLobbyResponse {
+	resp := make(chan CreateLobbyResponse, 1)
+	banchoBot := b.GetUser("BanchoBot")
+
+	var clearEvent func()
+	timer := time.AfterFunc(10*time.Second, func() {
+		select {
+		case resp <- CreateLobbyResponse{Error: ErrMessageTimeout}:
+			clearEvent()
+		default:
 		}
 	})
 
-	l.Channel.OnMessage(func(m *ChannelMessage) {
-		if strings.ToLower(m.User.ircUsername) == "banchobot" {
+	clearEvent = banchoBot.OnMessage(func(m *PrivateMessage) {
+		r := lobbyCreatedRegex.FindStringSubmatch(m.Content())
+		if r == nil || r[2] != name {
+			return
+		}
+
+		id, _ := strconv.Atoi(r[1])
+		lobby := b.GetLobby(id)
+		lobby.mu.Lock()
+		lobby.name = name
+		lobby.referees[strings.ToLower(b.Username)] = struct{}{}
+		lobby.mu.Unlock()
 
+		select {
+		case resp <- CreateLobbyResponse{Lobby: lobby}:
+			timer.Stop()
+			clearEvent()
+		default:
 		}
 	})
 
-	return &Lobby{Channel: c}
+	if err := banchoBot.SendMessage("!mp make " + name); err != nil {
+		timer.Stop()
+		clearEvent()
+		resp <- CreateLobbyResponse{Error: err}
+	}
+
+	return observeBanchoCommand(b, "mp make", resp, func(r CreateLobbyResponse) error { return r.Error })
 }
 
 func (l *Lobby) Name() string {
@@ -62,9 +146,134 @@ func (l *Lobby) SendMessage(message string) error {
 }
 
 func (l *Lobby) SendAction(message string) error {
-	return newOutgoingBanchoMessage(l.Client, l, message).Send()
+	return newOutgoingBanchoMessage(l.Client, l, EncodeCTCP("ACTION", message)).Send()
 }
 
 func (l *Lobby) Type() string {
 	return "mp"
 }
+
+// RoomName returns a lobby name, it is known after lobby was created by client or after UpdateSettings
+func (l *Lobby) RoomName() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.name
+}
+
+// BeatmapID returns current beatmap id, 0 if unknown
+func (l *Lobby) BeatmapID() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.beatmapId
+}
+
+// BeatmapTitle returns current beatmap title as BanchoBot shows it, e.g. "Artist - Title [Difficulty]"
+func (l *Lobby) BeatmapTitle() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.beatmap
+}
+
+// Mode returns a game mode set with SetMap, osu! standard by default
+func (l *Lobby) Mode() osuapi.Mode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mode
+}
+
+func (l *Lobby) TeamMode() TeamMode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.teamMode
+}
+
+func (l *Lobby) WinCondition() WinCondition {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.winCondition
+}
+
+// Mods returns enabled lobby mods and whether freemod is enabled
+func (l *Lobby) Mods() (osuapi.Mods, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mods, l.freemod
+}
+
+func (l *Lobby) IsPlaying() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.playing
+}
+
+func (l *Lobby) IsClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+func (l *Lobby) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Players returns copies of players in slot order
+func (l *Lobby) Players() []*LobbyPlayer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	players := make([]*LobbyPlayer, 0, maxLobbySize)
+	for _, p := range l.slots {
+		if p != nil {
+			players = append(players, copyPlayer(p))
+		}
+	}
+	return players
+}
+
+// Player returns a copy of lobby player, nil if user isn't in the lobby
+func (l *Lobby) Player(u *User) *LobbyPlayer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return copyPlayer(l.findPlayer(u))
+}
+
+// Host returns a copy of the current host, nil if lobby has no host
+func (l *Lobby) Host() *LobbyPlayer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, p := range l.slots {
+		if p != nil && p.IsHost {
+			return copyPlayer(p)
+		}
+	}
+	return nil
+}
+
+// IsReferee reports whether user is known to be a lobby referee.
+// Lobby knows about referees added with "!mp addref" and the client if it created the lobby.
+func (l *Lobby) IsReferee(u *User) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.referees[strings.ToLower(u.Name())]
+	return ok
+}
+
+// findPlayer must be called with l.mu locked
+func (l *Lobby) findPlayer(u *User) *LobbyPlayer {
+	for _, p := range l.slots {
+		if p != nil && p.User == u {
+			return p
+		}
+	}
+	return nil
+}
+
+func copyPlayer(p *LobbyPlayer) *LobbyPlayer {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
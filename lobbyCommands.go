@@ -0,0 +1,162 @@
+package banchogo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+// mp sends a "!mp" command to the lobby channel
+func (l *Lobby) mp(format string, a ...any) error {
+	return l.SendMessage("!mp " + fmt.Sprintf(format, a...))
+}
+
+// UpdateSettings sends "!mp settings" and waits until lobby state is updated from the response
+func (l *Lobby) UpdateSettings() <-chan error {
+	resp := make(chan error, 1)
+
+	var clearEvent func()
+	timer := time.AfterFunc(10*time.Second, func() {
+		select {
+		case resp <- ErrMessageTimeout:
+			clearEvent()
+		default:
+		}
+	})
+
+	clearEvent = l.OnceSettings(func() {
+		timer.Stop()
+		select {
+		case resp <- nil:
+		default:
+		}
+	})
+
+	if err := l.mp("settings"); err != nil {
+		timer.Stop()
+		clearEvent()
+		resp <- err
+	}
+
+	return observeBanchoCommand(l.Client, "mp settings", resp, func(err error) error { return err })
+}
+
+func (l *Lobby) SetHost(u *User) error {
+	return l.mp("host %s", u.Name())
+}
+
+func (l *Lobby) ClearHost() error {
+	return l.mp("clearhost")
+}
+
+// SetMap changes lobby beatmap. Mode is optional, by default lobby mode is left unchanged.
+func (l *Lobby) SetMap(beatmapId int, mode ...osuapi.Mode) error {
+	if len(mode) == 0 {
+		return l.mp("map %d", beatmapId)
+	}
+
+	err := l.mp("map %d %d", beatmapId, mode[0])
+	if err == nil {
+		l.mu.Lock()
+		l.mode = mode[0]
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// SetMods changes lobby mods, freemod allows players to pick their own mods
+func (l *Lobby) SetMods(mods osuapi.Mods, freemod bool) error {
+	args := strings.Fields(formatMpMods(mods))
+	if freemod {
+		args = append(args, "Freemod")
+	}
+	if len(args) == 0 {
+		args = []string{"None"}
+	}
+	return l.mp("mods %s", strings.Join(args, " "))
+}
+
+func (l *Lobby) SetName(name string) error {
+	return l.mp("name %s", name)
+}
+
+func (l *Lobby) SetPassword(password string) error {
+	return l.mp("password %s", password)
+}
+
+func (l *Lobby) SetSize(size int) error {
+	return l.mp("size %d", size)
+}
+
+// SetSettings changes team mode, win condition and optionally lobby size
+func (l *Lobby) SetSettings(teamMode TeamMode, winCondition WinCondition, size ...int) error {
+	if len(size) > 0 {
+		return l.mp("set %d %d %d", teamMode, winCondition, size[0])
+	}
+	return l.mp("set %d %d", teamMode, winCondition)
+}
+
+// Start starts the match, after delay if it is greater than zero
+func (l *Lobby) Start(delay ...time.Duration) error {
+	if len(delay) > 0 && delay[0] > 0 {
+		return l.mp("start %d", int(delay[0].Seconds()))
+	}
+	return l.mp("start")
+}
+
+func (l *Lobby) Abort() error {
+	return l.mp("abort")
+}
+
+// Timer starts a countdown, when it ends BanchoBot sends "Countdown finished"
+func (l *Lobby) Timer(d time.Duration) error {
+	return l.mp("timer %d", int(d.Seconds()))
+}
+
+func (l *Lobby) AbortTimer() error {
+	return l.mp("aborttimer")
+}
+
+func (l *Lobby) Invite(u *User) error {
+	return l.mp("invite %s", u.Name())
+}
+
+func (l *Lobby) Kick(u *User) error {
+	return l.mp("kick %s", u.Name())
+}
+
+func (l *Lobby) Ban(u *User) error {
+	return l.mp("ban %s", u.Name())
+}
+
+// Move moves a player to the slot, slots start from 1
+func (l *Lobby) Move(u *User, slot int) error {
+	return l.mp("move %s %d", u.Name(), slot)
+}
+
+func (l *Lobby) SetTeam(u *User, team Team) error {
+	return l.mp("team %s %s", u.Name(), team)
+}
+
+func (l *Lobby) Lock() error {
+	return l.mp("lock")
+}
+
+func (l *Lobby) Unlock() error {
+	return l.mp("unlock")
+}
+
+func (l *Lobby) AddReferee(u *User) error {
+	return l.mp("addref %s", u.Name())
+}
+
+func (l *Lobby) RemoveReferee(u *User) error {
+	return l.mp("removeref %s", u.Name())
+}
+
+// Close closes the lobby, BanchoBot kicks everyone from the channel
+func (l *Lobby) Close() error {
+	return l.mp("close")
+}
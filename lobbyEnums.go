@@ -1 +1,217 @@
 package banchogo
+
+import (
+	"strings"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+type TeamMode int
+
+const (
+	HeadToHead TeamMode = iota
+	TagCoop
+	TeamVs
+	TagTeamVs
+)
+
+var teamModeNames = [...]string{"HeadToHead", "TagCoop", "TeamVs", "TagTeamVs"}
+
+func (t TeamMode) String() string {
+	if t >= 0 && int(t) < len(teamModeNames) {
+		return teamModeNames[t]
+	}
+	return ""
+}
+
+// IsTeamMode reports whether players are split into red and blue teams
+func (t TeamMode) IsTeamMode() bool {
+	return t == TeamVs || t == TagTeamVs
+}
+
+func parseTeamMode(s string) TeamMode {
+	for i, n := range teamModeNames {
+		if n == s {
+			return TeamMode(i)
+		}
+	}
+	return HeadToHead
+}
+
+type WinCondition int
+
+const (
+	ScoreWinCondition WinCondition = iota
+	AccuracyWinCondition
+	ComboWinCondition
+	ScoreV2WinCondition
+)
+
+var winConditionNames = [...]string{"Score", "Accuracy", "Combo", "ScoreV2"}
+
+func (w WinCondition) String() string {
+	if w >= 0 && int(w) < len(winConditionNames) {
+		return winConditionNames[w]
+	}
+	return ""
+}
+
+func parseWinCondition(s string) WinCondition {
+	for i, n := range winConditionNames {
+		if n == s {
+			return WinCondition(i)
+		}
+	}
+	return ScoreWinCondition
+}
+
+type Team string
+
+const (
+	NoTeam   Team = ""
+	RedTeam  Team = "Red"
+	BlueTeam Team = "Blue"
+)
+
+func parseTeam(s string) Team {
+	switch strings.ToLower(s) {
+	case "red":
+		return RedTeam
+	case "blue":
+		return BlueTeam
+	default:
+		return NoTeam
+	}
+}
+
+type SlotState int
+
+const (
+	NotReady SlotState = iota
+	Ready
+	NoMap
+)
+
+func (s SlotState) String() string {
+	switch s {
+	case Ready:
+		return "Ready"
+	case NoMap:
+		return "No Map"
+	default:
+		return "Not Ready"
+	}
+}
+
+func parseSlotState(s string) SlotState {
+	switch s {
+	case "Ready":
+		return Ready
+	case "No Map":
+		return NoMap
+	default:
+		return NotReady
+	}
+}
+
+// banchoModNames are mod names used by BanchoBot in "Active mods" and "Enabled ..." messages
+var banchoModNames = map[string]osuapi.Mods{
+	"NoFail":      osuapi.ModNoFail,
+	"Easy":        osuapi.ModEasy,
+	"TouchDevice": osuapi.ModNoVideo,
+	"Hidden":      osuapi.ModHidden,
+	"HardRock":    osuapi.ModHardRock,
+	"SuddenDeath": osuapi.ModSuddenDeath,
+	"DoubleTime":  osuapi.ModDoubleTime,
+	"Relax":       osuapi.ModRelax,
+	"HalfTime":    osuapi.ModHalfTime,
+	"Nightcore":   osuapi.ModNightcore | osuapi.ModDoubleTime,
+	"Flashlight":  osuapi.ModFlashlight,
+	"Autoplay":    osuapi.ModAutoplay,
+	"SpunOut":     osuapi.ModSpunOut,
+	"Relax2":      osuapi.ModRelax2,
+	"Autopilot":   osuapi.ModRelax2,
+	"Perfect":     osuapi.ModPerfect | osuapi.ModSuddenDeath,
+	"Key4":        osuapi.ModKey4,
+	"Key5":        osuapi.ModKey5,
+	"Key6":        osuapi.ModKey6,
+	"Key7":        osuapi.ModKey7,
+	"Key8":        osuapi.ModKey8,
+	"FadeIn":      osuapi.ModFadeIn,
+	"Random":      osuapi.ModRandom,
+	"Key9":        osuapi.ModKey9,
+	"Key1":        osuapi.ModKey1,
+	"Key3":        osuapi.ModKey3,
+	"Key2":        osuapi.ModKey2,
+}
+
+// parseBanchoMods parses comma separated mod names like "Hidden, DoubleTime, Freemod".
+// Freemod isn't a mod, so it is returned separately.
+func parseBanchoMods(s string) (mods osuapi.Mods, freemod bool) {
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, "Freemod") {
+			freemod = true
+			continue
+		}
+		mods |= banchoModNames[name]
+	}
+	return
+}
+
+// formatMpMods formats mods the way "!mp mods" accepts them, e.g. "HD DT"
+func formatMpMods(mods osuapi.Mods) string {
+	if mods&osuapi.ModNightcore != 0 {
+		mods &^= osuapi.ModDoubleTime
+	}
+	if mods&osuapi.ModPerfect != 0 {
+		mods &^= osuapi.ModSuddenDeath
+	}
+
+	s := mods.String()
+	acronyms := make([]string, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		acronyms = append(acronyms, s[i:i+2])
+	}
+	return strings.Join(acronyms, " ")
+}
+
+// UserStatus is an in-game status shown by BanchoBot's "!stats"
+type UserStatus int
+
+const (
+	StatusUnknown UserStatus = iota
+	StatusIdle
+	StatusAfk
+	StatusPlaying
+	StatusMultiplaying
+	StatusMultiplayer
+	StatusModding
+	StatusEditing
+	StatusTesting
+	StatusSubmitting
+	StatusWatching
+	StatusLobby
+	StatusPaused
+	StatusOsuDirect
+)
+
+var userStatusNames = [...]string{"Unknown", "Idle", "Afk", "Playing", "Multiplaying", "Multiplayer", "Modding",
+	"Editing", "Testing", "Submitting", "Watching", "Lobby", "Paused", "OsuDirect"}
+
+func (s UserStatus) String() string {
+	if s >= 0 && int(s) < len(userStatusNames) {
+		return userStatusNames[s]
+	}
+	return userStatusNames[StatusUnknown]
+}
+
+func parseUserStatus(s string) UserStatus {
+	s = strings.ReplaceAll(s, "!", "")
+	for i, n := range userStatusNames {
+		if strings.EqualFold(n, s) {
+			return UserStatus(i)
+		}
+	}
+	return StatusUnknown
+}
@@ -0,0 +1,134 @@
+package banchogo
+
+func (l *Lobby) OnPlayerJoined(handler func(*LobbyPlayer)) func() {
+	return l.ev.On("PlayerJoined", handler)
+}
+
+func (l *Lobby) OncePlayerJoined(handler func(*LobbyPlayer)) func() {
+	return l.ev.Once("PlayerJoined", handler)
+}
+
+func (l *Lobby) OnPlayerLeft(handler func(*LobbyPlayer)) func() {
+	return l.ev.On("PlayerLeft", handler)
+}
+
+func (l *Lobby) OncePlayerLeft(handler func(*LobbyPlayer)) func() {
+	return l.ev.Once("PlayerLeft", handler)
+}
+
+func (l *Lobby) OnPlayerMoved(handler func(*LobbyPlayer)) func() {
+	return l.ev.On("PlayerMoved", handler)
+}
+
+func (l *Lobby) OncePlayerMoved(handler func(*LobbyPlayer)) func() {
+	return l.ev.Once("PlayerMoved", handler)
+}
+
+func (l *Lobby) OnPlayerChangedTeam(handler func(*LobbyPlayer)) func() {
+	return l.ev.On("PlayerChangedTeam", handler)
+}
+
+func (l *Lobby) OncePlayerChangedTeam(handler func(*LobbyPlayer)) func() {
+	return l.ev.Once("PlayerChangedTeam", handler)
+}
+
+// OnHost is emitted when host changes, player is nil when host was cleared
+func (l *Lobby) OnHost(handler func(*LobbyPlayer)) func() {
+	return l.ev.On("Host", handler)
+}
+
+func (l *Lobby) OnceHost(handler func(*LobbyPlayer)) func() {
+	return l.ev.Once("Host", handler)
+}
+
+func (l *Lobby) OnHostChangingMap(handler func()) func() {
+	return l.ev.On("HostChangingMap", handler)
+}
+
+func (l *Lobby) OnceHostChangingMap(handler func()) func() {
+	return l.ev.Once("HostChangingMap", handler)
+}
+
+// OnBeatmapChanged is emitted with beatmap id and title when host or referee changes the map
+func (l *Lobby) OnBeatmapChanged(handler func(int, string)) func() {
+	return l.ev.On("BeatmapChanged", handler)
+}
+
+func (l *Lobby) OnceBeatmapChanged(handler func(int, string)) func() {
+	return l.ev.Once("BeatmapChanged", handler)
+}
+
+// OnBeatmapResolved is emitted when metadata of the current beatmap is fetched from the API
+func (l *Lobby) OnBeatmapResolved(handler func(*Beatmap)) func() {
+	return l.ev.On("BeatmapResolved", handler)
+}
+
+func (l *Lobby) OnceBeatmapResolved(handler func(*Beatmap)) func() {
+	return l.ev.Once("BeatmapResolved", handler)
+}
+
+func (l *Lobby) OnModsChanged(handler func()) func() {
+	return l.ev.On("ModsChanged", handler)
+}
+
+func (l *Lobby) OnceModsChanged(handler func()) func() {
+	return l.ev.Once("ModsChanged", handler)
+}
+
+func (l *Lobby) OnMatchStarted(handler func()) func() {
+	return l.ev.On("MatchStarted", handler)
+}
+
+func (l *Lobby) OnceMatchStarted(handler func()) func() {
+	return l.ev.Once("MatchStarted", handler)
+}
+
+func (l *Lobby) OnMatchFinished(handler func(*MatchResult)) func() {
+	return l.ev.On("MatchFinished", handler)
+}
+
+func (l *Lobby) OnceMatchFinished(handler func(*MatchResult)) func() {
+	return l.ev.Once("MatchFinished", handler)
+}
+
+func (l *Lobby) OnMatchAborted(handler func()) func() {
+	return l.ev.On("MatchAborted", handler)
+}
+
+func (l *Lobby) OnceMatchAborted(handler func()) func() {
+	return l.ev.Once("MatchAborted", handler)
+}
+
+func (l *Lobby) OnAllPlayersReady(handler func()) func() {
+	return l.ev.On("AllPlayersReady", handler)
+}
+
+func (l *Lobby) OnceAllPlayersReady(handler func()) func() {
+	return l.ev.Once("AllPlayersReady", handler)
+}
+
+// OnSettings is emitted after lobby state was updated from "!mp settings" response
+func (l *Lobby) OnSettings(handler func()) func() {
+	return l.ev.On("Settings", handler)
+}
+
+func (l *Lobby) OnceSettings(handler func()) func() {
+	return l.ev.Once("Settings", handler)
+}
+
+func (l *Lobby) OnClosed(handler func()) func() {
+	return l.ev.On("Closed", handler)
+}
+
+func (l *Lobby) OnceClosed(handler func()) func() {
+	return l.ev.Once("Closed", handler)
+}
+
+// OnRoll is called when BanchoBot answers to "!roll" in the lobby
+func (l *Lobby) OnRoll(handler func(*RollResult)) func() {
+	return l.ev.On("Roll", handler)
+}
+
+func (l *Lobby) OnceRoll(handler func(*RollResult)) func() {
+	return l.ev.Once("Roll", handler)
+}
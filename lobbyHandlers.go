@@ -0,0 +1,426 @@
+package banchogo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type lobbyHandler struct {
+	regex   *regexp.Regexp
+	handler func(l *Lobby, r []string)
+}
+
+// lobbyHandlers parse BanchoBot messages in a multiplayer channel, first matching handler wins
+var lobbyHandlers = []lobbyHandler{
+	{regexp.MustCompile(`^Room name: (.+), History: https://osu\.ppy\.sh/mp/(\d+)$`), handleLobbySettingsName},
+	{regexp.MustCompile(`^Beatmap: https://osu\.ppy\.sh/b/(\d+) (.+)$`), handleLobbySettingsBeatmap},
+	{regexp.MustCompile(`^Team mode: (.+), Win condition: (.+)$`), handleLobbySettingsTeamMode},
+	{regexp.MustCompile(`^Active mods: (.+)$`), handleLobbySettingsMods},
+	{regexp.MustCompile(`^Players: (\d+)$`), handleLobbySettingsPlayers},
+	{regexp.MustCompile(`^Slot (\d+) +(Not Ready|Ready|No Map) +https://osu\.ppy\.sh/u/(\d+) (.+?)(?: +\[(.+)\])?$`), handleLobbySettingsSlot},
+	{regexp.MustCompile(`^(.+) joined in slot (\d+)(?: for team (red|blue))?\.$`), handleLobbyPlayerJoined},
+	{regexp.MustCompile(`^(.+) left the game\.$`), handleLobbyPlayerLeft},
+	{regexp.MustCompile(`^(.+) moved to slot (\d+)$`), handleLobbyPlayerMoved},
+	{regexp.MustCompile(`^(.+) changed to (Red|Blue)$`), handleLobbyPlayerChangedTeam},
+	{regexp.MustCompile(`^(.+) became the host\.$`), handleLobbyHost},
+	{regexp.MustCompile(`^Changed match host to (.+)$`), handleLobbyHost},
+	{regexp.MustCompile(`^Cleared match host$`), handleLobbyHostCleared},
+	{regexp.MustCompile(`^Host is changing map\.\.\.$`), handleLobbyHostChangingMap},
+	{regexp.MustCompile(`^Beatmap changed to: (.+) \(https://osu\.ppy\.sh/b/(\d+)\)$`), handleLobbyBeatmapChanged},
+	{regexp.MustCompile(`^Changed beatmap to https://osu\.ppy\.sh/b/(\d+) (.+)$`), handleLobbyRefBeatmapChanged},
+	{regexp.MustCompile(`^(?:Enabled (.+)|Disabled all mods), (enabled|disabled) FreeMod$`), handleLobbyModsChanged},
+	{regexp.MustCompile(`^Changed match settings to (?:(\d+) slots, )?(HeadToHead|TagCoop|TeamVs|TagTeamVs)(?:, (Score|Accuracy|Combo|ScoreV2))?$`), handleLobbySettingsChanged},
+	{regexp.MustCompile(`^Changed match to size (\d+)$`), handleLobbySizeChanged},
+	{regexp.MustCompile(`^The match has started!$`), handleLobbyMatchStarted},
+	{regexp.MustCompile(`^(.+) finished playing \(Score: (\d+), (PASSED|FAILED)\)\.$`), handleLobbyPlayerFinished},
+	{regexp.MustCompile(`^The match has finished!$`), handleLobbyMatchFinished},
+	{regexp.MustCompile(`^Aborted the match$`), handleLobbyMatchAborted},
+	{regexp.MustCompile(`^All players are ready$`), handleLobbyAllPlayersReady},
+	{regexp.MustCompile(`^Added (.+) to the match referees$`), handleLobbyRefereeAdded},
+	{regexp.MustCompile(`^Removed (.+) from the match referees$`), handleLobbyRefereeRemoved},
+	{regexp.MustCompile(`^Closed the match$`), handleLobbyClosed},
+}
+
+func (l *Lobby) handleBanchoBotMessage(content string) {
+	for _, h := range lobbyHandlers {
+		if r := h.regex.FindStringSubmatch(content); r != nil {
+			h.handler(l, r)
+			return
+		}
+	}
+}
+
+func handleLobbySettingsName(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.name = r[1]
+	l.mods, l.freemod = 0, false
+	l.settings = &lobbySettings{expectedPlayers: -1}
+	l.mu.Unlock()
+}
+
+func handleLobbySettingsBeatmap(l *Lobby, r []string) {
+	id, _ := strconv.Atoi(r[1])
+
+	l.mu.Lock()
+	changed := l.beatmapId != id
+	l.beatmapId = id
+	l.beatmap = r[2]
+	l.mu.Unlock()
+
+	if changed {
+		l.resolveBeatmapAsync()
+	}
+}
+
+func handleLobbySettingsTeamMode(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.teamMode = parseTeamMode(r[1])
+	l.winCondition = parseWinCondition(r[2])
+	l.mu.Unlock()
+}
+
+func handleLobbySettingsMods(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.mods, l.freemod = parseBanchoMods(r[1])
+	l.mu.Unlock()
+}
+
+func handleLobbySettingsPlayers(l *Lobby, r []string) {
+	l.mu.Lock()
+	if l.settings == nil {
+		l.mu.Unlock()
+		return
+	}
+	l.settings.expectedPlayers, _ = strconv.Atoi(r[1])
+	l.mu.Unlock()
+
+	l.finishSettings()
+}
+
+func handleLobbySettingsSlot(l *Lobby, r []string) {
+	slot, _ := strconv.Atoi(r[1])
+	p := &LobbyPlayer{
+		User:  l.Client.GetUser(strings.TrimSpace(r[4])),
+		Slot:  slot,
+		State: parseSlotState(r[2]),
+	}
+	p.UserID, _ = strconv.Atoi(r[3])
+
+	for _, part := range strings.Split(r[5], " / ") {
+		switch {
+		case part == "Host":
+			p.IsHost = true
+		case strings.HasPrefix(part, "Team "):
+			p.Team = parseTeam(strings.TrimPrefix(part, "Team "))
+		case part != "":
+			p.Mods, _ = parseBanchoMods(part)
+		}
+	}
+
+	l.mu.Lock()
+	if l.settings == nil {
+		l.mu.Unlock()
+		return
+	}
+	l.settings.players = append(l.settings.players, p)
+	l.mu.Unlock()
+
+	l.finishSettings()
+}
+
+// finishSettings replaces lobby slots when all players from "!mp settings" response are received
+func (l *Lobby) finishSettings() {
+	l.mu.Lock()
+	s := l.settings
+	if s == nil || s.expectedPlayers < 0 || len(s.players) < s.expectedPlayers {
+		l.mu.Unlock()
+		return
+	}
+
+	l.settings = nil
+	l.slots = [maxLobbySize]*LobbyPlayer{}
+	for _, p := range s.players {
+		if p.Slot >= 1 && p.Slot <= maxLobbySize {
+			l.slots[p.Slot-1] = p
+		}
+	}
+	l.mu.Unlock()
+
+	l.ev.Emit("Settings")
+}
+
+func handleLobbyPlayerJoined(l *Lobby, r []string) {
+	slot, _ := strconv.Atoi(r[2])
+	p := &LobbyPlayer{
+		User: l.Client.GetUser(r[1]),
+		Slot: slot,
+		Team: parseTeam(r[3]),
+	}
+
+	l.mu.Lock()
+	if old := l.findPlayer(p.User); old != nil {
+		l.slots[old.Slot-1] = nil
+	}
+	if slot >= 1 && slot <= maxLobbySize {
+		l.slots[slot-1] = p
+	}
+	p = copyPlayer(p)
+	l.mu.Unlock()
+
+	l.ev.Emit("PlayerJoined", p)
+}
+
+func handleLobbyPlayerLeft(l *Lobby, r []string) {
+	user := l.Client.GetUser(r[1])
+
+	l.mu.Lock()
+	p := l.findPlayer(user)
+	if p == nil {
+		l.mu.Unlock()
+		return
+	}
+	l.slots[p.Slot-1] = nil
+	l.mu.Unlock()
+
+	l.ev.Emit("PlayerLeft", p)
+}
+
+func handleLobbyPlayerMoved(l *Lobby, r []string) {
+	user := l.Client.GetUser(r[1])
+	slot, _ := strconv.Atoi(r[2])
+	if slot < 1 || slot > maxLobbySize {
+		return
+	}
+
+	l.mu.Lock()
+	p := l.findPlayer(user)
+	if p == nil {
+		p = &LobbyPlayer{User: user}
+	} else {
+		l.slots[p.Slot-1] = nil
+	}
+	p.Slot = slot
+	l.slots[slot-1] = p
+	p = copyPlayer(p)
+	l.mu.Unlock()
+
+	l.ev.Emit("PlayerMoved", p)
+}
+
+func handleLobbyPlayerChangedTeam(l *Lobby, r []string) {
+	user := l.Client.GetUser(r[1])
+
+	l.mu.Lock()
+	p := l.findPlayer(user)
+	if p == nil {
+		l.mu.Unlock()
+		return
+	}
+	p.Team = parseTeam(r[2])
+	p = copyPlayer(p)
+	l.mu.Unlock()
+
+	l.ev.Emit("PlayerChangedTeam", p)
+}
+
+func handleLobbyHost(l *Lobby, r []string) {
+	user := l.Client.GetUser(r[1])
+
+	l.mu.Lock()
+	var host *LobbyPlayer
+	changed := false
+	for _, p := range l.slots {
+		if p == nil {
+			continue
+		}
+		isHost := p.User == user
+		if isHost != p.IsHost {
+			changed = true
+		}
+		p.IsHost = isHost
+		if isHost {
+			host = copyPlayer(p)
+		}
+	}
+	l.mu.Unlock()
+
+	if changed {
+		l.ev.Emit("Host", host)
+	}
+}
+
+func handleLobbyHostCleared(l *Lobby, _ []string) {
+	l.mu.Lock()
+	changed := false
+	for _, p := range l.slots {
+		if p != nil && p.IsHost {
+			p.IsHost = false
+			changed = true
+		}
+	}
+	l.mu.Unlock()
+
+	if changed {
+		l.ev.Emit("Host", (*LobbyPlayer)(nil))
+	}
+}
+
+func handleLobbyHostChangingMap(l *Lobby, _ []string) {
+	l.ev.Emit("HostChangingMap")
+}
+
+func handleLobbyBeatmapChanged(l *Lobby, r []string) {
+	id, _ := strconv.Atoi(r[2])
+	l.setBeatmap(id, r[1])
+}
+
+func handleLobbyRefBeatmapChanged(l *Lobby, r []string) {
+	id, _ := strconv.Atoi(r[1])
+	l.setBeatmap(id, r[2])
+}
+
+func (l *Lobby) setBeatmap(id int, title string) {
+	l.mu.Lock()
+	l.beatmapId = id
+	l.beatmap = title
+	l.mu.Unlock()
+
+	l.ev.Emit("BeatmapChanged", id, title)
+	l.resolveBeatmapAsync()
+}
+
+func handleLobbyModsChanged(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.mods, _ = parseBanchoMods(r[1])
+	l.freemod = r[2] == "enabled"
+	if !l.freemod {
+		for _, p := range l.slots {
+			if p != nil {
+				p.Mods = 0
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	l.ev.Emit("ModsChanged")
+}
+
+func handleLobbySettingsChanged(l *Lobby, r []string) {
+	l.mu.Lock()
+	if r[1] != "" {
+		l.size, _ = strconv.Atoi(r[1])
+	}
+	l.teamMode = parseTeamMode(r[2])
+	if r[3] != "" {
+		l.winCondition = parseWinCondition(r[3])
+	}
+	l.mu.Unlock()
+}
+
+func handleLobbySizeChanged(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.size, _ = strconv.Atoi(r[1])
+	l.mu.Unlock()
+}
+
+func handleLobbyMatchStarted(l *Lobby, _ []string) {
+	l.mu.Lock()
+	l.playing = true
+	l.startedAt = time.Now()
+	l.scores = nil
+	l.mu.Unlock()
+
+	l.ev.Emit("MatchStarted")
+}
+
+func handleLobbyPlayerFinished(l *Lobby, r []string) {
+	user := l.Client.GetUser(r[1])
+	score := MatchScore{User: user, Passed: r[3] == "PASSED"}
+	score.Score, _ = strconv.Atoi(r[2])
+
+	l.mu.Lock()
+	if p := l.findPlayer(user); p != nil {
+		score.UserID = p.UserID
+		score.Slot = p.Slot
+		score.Team = p.Team
+		score.Mods = p.Mods
+	}
+	l.scores = append(l.scores, score)
+	l.mu.Unlock()
+}
+
+func handleLobbyMatchFinished(l *Lobby, _ []string) {
+	l.mu.Lock()
+	result := &MatchResult{
+		LobbyID:      l.Id,
+		BeatmapID:    l.beatmapId,
+		Beatmap:      l.beatmap,
+		Mode:         l.mode,
+		Mods:         l.mods,
+		FreeMod:      l.freemod,
+		TeamMode:     l.teamMode,
+		WinCondition: l.winCondition,
+		StartedAt:    l.startedAt,
+		FinishedAt:   time.Now(),
+		Scores:       l.scores,
+	}
+	l.playing = false
+	l.scores = nil
+	for _, p := range l.slots {
+		if p != nil {
+			p.State = NotReady
+		}
+	}
+	l.mu.Unlock()
+
+	l.ev.Emit("MatchFinished", result)
+}
+
+func handleLobbyMatchAborted(l *Lobby, _ []string) {
+	l.mu.Lock()
+	l.playing = false
+	l.scores = nil
+	l.mu.Unlock()
+
+	l.ev.Emit("MatchAborted")
+}
+
+func handleLobbyAllPlayersReady(l *Lobby, _ []string) {
+	l.mu.Lock()
+	for _, p := range l.slots {
+		if p != nil {
+			p.State = Ready
+		}
+	}
+	l.mu.Unlock()
+
+	l.ev.Emit("AllPlayersReady")
+}
+
+func handleLobbyRefereeAdded(l *Lobby, r []string) {
+	l.mu.Lock()
+	l.referees[strings.ToLower(l.Client.GetUser(r[1]).Name())] = struct{}{}
+	l.mu.Unlock()
+}
+
+func handleLobbyRefereeRemoved(l *Lobby, r []string) {
+	l.mu.Lock()
+	delete(l.referees, strings.ToLower(l.Client.GetUser(r[1]).Name()))
+	l.mu.Unlock()
+}
+
+func handleLobbyClosed(l *Lobby, _ []string) {
+	l.mu.Lock()
+	l.closed = true
+	l.playing = false
+	l.mu.Unlock()
+
+	l.ev.Emit("Closed")
+
+	for _, f := range l.handlerRemovers {
+		f()
+	}
+	l.Client.Lobbies.Delete(l.Name())
+}
@@ -1 +1,33 @@
 package banchogo
+
+import "github.com/thehowl/go-osuapi"
+
+// LobbyPlayer a player in multiplayer lobby slot.
+// Objects returned by Lobby methods and events are copies, so they are safe to read from any goroutine.
+type LobbyPlayer struct {
+	User *User
+	// UserID is known only after Lobby.UpdateSettings
+	UserID int
+
+	// Slot starts from 1 like in BanchoBot messages
+	Slot  int
+	Team  Team
+	State SlotState
+	// Mods are player's mods when freemod is enabled, known only after Lobby.UpdateSettings
+	Mods osuapi.Mods
+
+	IsHost bool
+}
+
+// MatchScore is a player score reported by BanchoBot after the player finished playing
+type MatchScore struct {
+	User *User
+	// UserID is known only if lobby settings were updated
+	UserID int
+	Slot   int
+	Team   Team
+	Score  int
+	Passed bool
+	// Mods are player's mods at the start of the match, known only if lobby settings were updated
+	Mods osuapi.Mods
+}
@@ -0,0 +1,123 @@
+package banchogo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func newTestLobby() *Lobby {
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+	return b.GetLobby(123)
+}
+
+func feedLobby(l *Lobby, lines ...string) {
+	for _, line := range lines {
+		l.handleBanchoBotMessage(line)
+	}
+}
+
+func TestLobby_Settings(t *testing.T) {
+	l := newTestLobby()
+	settings := 0
+	l.OnSettings(func() { settings++ })
+
+	feedLobby(l,
+		"Room name: test lobby, History: https://osu.ppy.sh/mp/123",
+		"Beatmap: https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE [Normal]",
+		"Team mode: TeamVs, Win condition: ScoreV2",
+		"Active mods: Hidden, Freemod",
+		"Players: 2",
+		"Slot 1  Not Ready https://osu.ppy.sh/u/2 peppy            [Host / Team Red / HardRock]",
+		"Slot 4  Ready     https://osu.ppy.sh/u/3 Some Player      [Team Blue]",
+	)
+
+	if settings != 1 {
+		t.Fatalf("expected settings event once, got %d", settings)
+	}
+	if l.RoomName() != "test lobby" || l.BeatmapID() != 75 || l.TeamMode() != TeamVs || l.WinCondition() != ScoreV2WinCondition {
+		t.Error("lobby settings weren't parsed")
+	}
+	if mods, freemod := l.Mods(); mods != osuapi.ModHidden || !freemod {
+		t.Errorf("unexpected mods %s, freemod %v", mods, freemod)
+	}
+
+	players := l.Players()
+	if len(players) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(players))
+	}
+	if p := players[0]; p.User.Name() != "peppy" || p.UserID != 2 || !p.IsHost || p.Team != RedTeam || p.Mods != osuapi.ModHardRock {
+		t.Errorf("unexpected player %+v", p)
+	}
+	if p := players[1]; p.User.Name() != "Some_Player" || p.Slot != 4 || p.State != Ready || p.Team != BlueTeam {
+		t.Errorf("unexpected player %+v", p)
+	}
+}
+
+func TestLobby_Match(t *testing.T) {
+	l := newTestLobby()
+
+	var (
+		joined []*LobbyPlayer
+		host   *LobbyPlayer
+		result *MatchResult
+	)
+	l.OnPlayerJoined(func(p *LobbyPlayer) { joined = append(joined, p) })
+	l.OnHost(func(p *LobbyPlayer) { host = p })
+	l.OnMatchFinished(func(r *MatchResult) { result = r })
+
+	feedLobby(l,
+		"First Player joined in slot 1 for team red.",
+		"second joined in slot 2 for team blue.",
+		"First Player became the host.",
+		"Changed beatmap to https://osu.ppy.sh/b/100 Artist - Title [Hard]",
+		"second moved to slot 5",
+		"The match has started!",
+		"First Player finished playing (Score: 1000000, PASSED).",
+		"second finished playing (Score: 12345, FAILED).",
+		"The match has finished!",
+		"second left the game.",
+	)
+
+	if len(joined) != 2 || joined[0].Team != RedTeam || joined[1].Slot != 2 {
+		t.Errorf("unexpected joined players %v", joined)
+	}
+	if host == nil || host.User.Name() != "First_Player" {
+		t.Errorf("unexpected host %v", host)
+	}
+	if result == nil {
+		t.Fatal("match finished event wasn't emitted")
+	}
+	if result.BeatmapID != 100 || len(result.Scores) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if s := result.Scores[1]; s.Slot != 5 || s.Team != BlueTeam || s.Passed || s.Score != 12345 {
+		t.Errorf("unexpected score %+v", s)
+	}
+	if l.IsPlaying() {
+		t.Error("lobby is still playing")
+	}
+	if players := l.Players(); len(players) != 1 {
+		t.Errorf("expected 1 player after leave, got %d", len(players))
+	}
+}
+
+func TestLobby_SetMods(t *testing.T) {
+	b, conn := newTestClient(t)
+	l := b.GetLobby(1)
+	l.SetMods(osuapi.ModHidden|osuapi.ModHardRock, false)
+	l.SetMods(0, true)
+	l.SetMods(osuapi.ModHidden, true)
+	l.SetMods(0, false)
+
+	want := []string{
+		"PRIVMSG #mp_1 :!mp mods HD HR",
+		"PRIVMSG #mp_1 :!mp mods Freemod",
+		"PRIVMSG #mp_1 :!mp mods HD Freemod",
+		"PRIVMSG #mp_1 :!mp mods None",
+	}
+	if sent := conn.Sent()[3:]; !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+}
@@ -0,0 +1,275 @@
+package banchogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thehowl/go-osuapi"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownMappoolFormat = errors.New("unknown mappool format, expected json or yaml")
+	ErrPoolSlotMismatch     = errors.New("lobby state doesn't match the pool slot")
+)
+
+// slotPrefixMods are mods inferred from a slot name when mods are not set explicitly
+var slotPrefixMods = map[string]osuapi.Mods{
+	"NM": 0,
+	"HD": osuapi.ModHidden,
+	"HR": osuapi.ModHardRock,
+	"DT": osuapi.ModDoubleTime,
+	"NC": osuapi.ModNightcore | osuapi.ModDoubleTime,
+	"HT": osuapi.ModHalfTime,
+	"EZ": osuapi.ModEasy,
+	"FL": osuapi.ModFlashlight,
+}
+
+// PoolSlot is a single mappool map like "NM1" or "TB"
+type PoolSlot struct {
+	Slot      string
+	BeatmapID int
+	Mode      osuapi.Mode
+	Mods      osuapi.Mods
+	FreeMod   bool
+}
+
+// Mappool is a set of tournament maps. It can be loaded from JSON or YAML file:
+//
+//	name: Some Tournament QF
+//	mode: osu
+//	maps:
+//	  - slot: NM1
+//	    beatmap_id: 75
+//	  - slot: HD1
+//	    beatmap_id: 129891
+//	  - slot: EZ1
+//	    beatmap_id: 1262832
+//	    mods: EZ NF
+//	  - slot: TB
+//	    beatmap_id: 658127
+//
+// Mods are inferred from the slot name prefix when omitted (NM, HD, HR, DT, NC, HT, EZ, FL),
+// FM and TB slots are freemod by default.
+type Mappool struct {
+	Name  string
+	Mode  osuapi.Mode
+	Slots []PoolSlot
+}
+
+type mappoolFile struct {
+	Name string         `json:"name" yaml:"name"`
+	Mode string         `json:"mode" yaml:"mode"`
+	Maps []poolSlotFile `json:"maps" yaml:"maps"`
+}
+
+type poolSlotFile struct {
+	Slot      string `json:"slot" yaml:"slot"`
+	BeatmapID int    `json:"beatmap_id" yaml:"beatmap_id"`
+	Mode      string `json:"mode" yaml:"mode"`
+	Mods      string `json:"mods" yaml:"mods"`
+	FreeMod   *bool  `json:"freemod" yaml:"freemod"`
+}
+
+// LoadMappool reads a mappool from .json, .yaml or .yml file
+func LoadMappool(path string) (*Mappool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMappool(data, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// ParseMappool parses a mappool in "json" or "yaml" format and validates it
+func ParseMappool(data []byte, format string) (*Mappool, error) {
+	var f mappoolFile
+	var err error
+
+	switch strings.ToLower(format) {
+	case "json":
+		err = json.Unmarshal(data, &f)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &f)
+	default:
+		return nil, ErrUnknownMappoolFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &Mappool{Name: f.Name}
+	if pool.Mode, err = parseMode(f.Mode); err != nil {
+		return nil, err
+	}
+
+	for _, s := range f.Maps {
+		slot := PoolSlot{
+			Slot:      strings.ToUpper(strings.TrimSpace(s.Slot)),
+			BeatmapID: s.BeatmapID,
+			Mode:      pool.Mode,
+		}
+		if s.Mode != "" {
+			if slot.Mode, err = parseMode(s.Mode); err != nil {
+				return nil, fmt.Errorf("slot %s: %w", slot.Slot, err)
+			}
+		}
+
+		prefix := strings.TrimRight(slot.Slot, "0123456789")
+		if s.Mods != "" {
+			if slot.Mods, err = parseModAcronyms(s.Mods); err != nil {
+				return nil, fmt.Errorf("slot %s: %w", slot.Slot, err)
+			}
+		} else {
+			slot.Mods = slotPrefixMods[prefix]
+		}
+
+		if s.FreeMod != nil {
+			slot.FreeMod = *s.FreeMod
+		} else {
+			slot.FreeMod = prefix == "FM" || prefix == "TB"
+		}
+
+		pool.Slots = append(pool.Slots, slot)
+	}
+
+	return pool, pool.Validate()
+}
+
+// Validate checks that every slot has a unique name and a beatmap id
+func (p *Mappool) Validate() error {
+	seen := make(map[string]struct{}, len(p.Slots))
+	for i, s := range p.Slots {
+		if s.Slot == "" {
+			return fmt.Errorf("map #%d has no slot name", i+1)
+		}
+		if _, ok := seen[s.Slot]; ok {
+			return fmt.Errorf("slot %s is defined twice", s.Slot)
+		}
+		seen[s.Slot] = struct{}{}
+
+		if s.BeatmapID <= 0 {
+			return fmt.Errorf("slot %s has no beatmap id", s.Slot)
+		}
+		if s.Mode < osuapi.ModeOsu || s.Mode > osuapi.ModeOsuMania {
+			return fmt.Errorf("slot %s has invalid mode %d", s.Slot, s.Mode)
+		}
+	}
+	return nil
+}
+
+// Slot finds a slot by its name, case-insensitive
+func (p *Mappool) Slot(name string) (PoolSlot, bool) {
+	for _, s := range p.Slots {
+		if strings.EqualFold(s.Slot, name) {
+			return s, true
+		}
+	}
+	return PoolSlot{}, false
+}
+
+// ApplyPoolSlot runs "!mp map" and "!mp mods" for the slot and waits until BanchoBot confirms both changes.
+// Returns ErrPoolSlotMismatch if BanchoBot applied something else.
+func (l *Lobby) ApplyPoolSlot(slot PoolSlot) <-chan error {
+	resp := make(chan error, 1)
+
+	beatmapChanged := make(chan struct{})
+	modsChanged := make(chan struct{})
+	removers := []func(){
+		l.OnceBeatmapChanged(func(int, string) { close(beatmapChanged) }),
+		l.OnceModsChanged(func() { close(modsChanged) }),
+	}
+	clearEvents := func() {
+		for _, f := range removers {
+			f()
+		}
+	}
+
+	go func() {
+		defer clearEvents()
+
+		if err := l.SetMap(slot.BeatmapID, slot.Mode); err != nil {
+			resp <- err
+			return
+		}
+		if err := l.SetMods(slot.Mods, slot.FreeMod); err != nil {
+			resp <- err
+			return
+		}
+
+		timeout := time.After(10 * time.Second)
+		for _, c := range []chan struct{}{beatmapChanged, modsChanged} {
+			select {
+			case <-c:
+			case <-timeout:
+				resp <- ErrMessageTimeout
+				return
+			}
+		}
+
+		mods, freemod := l.Mods()
+		if id := l.BeatmapID(); id != slot.BeatmapID {
+			resp <- fmt.Errorf("%w: expected beatmap %d, got %d", ErrPoolSlotMismatch, slot.BeatmapID, id)
+			return
+		}
+		if normalizeMods(mods) != normalizeMods(slot.Mods) || freemod != slot.FreeMod {
+			resp <- fmt.Errorf("%w: expected mods %q (freemod %v), got %q (freemod %v)",
+				ErrPoolSlotMismatch, slot.Mods, slot.FreeMod, mods, freemod)
+			return
+		}
+		resp <- nil
+	}()
+
+	return resp
+}
+
+// normalizeMods adds mods implied by other mods, so NC equals NCDT and PF equals PFSD
+func normalizeMods(mods osuapi.Mods) osuapi.Mods {
+	if mods&osuapi.ModNightcore != 0 {
+		mods |= osuapi.ModDoubleTime
+	}
+	if mods&osuapi.ModPerfect != 0 {
+		mods |= osuapi.ModSuddenDeath
+	}
+	return mods
+}
+
+// parseModAcronyms parses mods like "HDHR", "HD HR" or "HD,HR" and fails on unknown acronyms
+func parseModAcronyms(s string) (osuapi.Mods, error) {
+	s = strings.NewReplacer(" ", "", ",", "", "+", "").Replace(strings.ToUpper(s))
+	if s == "NM" || s == "NOMOD" {
+		return 0, nil
+	}
+	if len(s)%2 != 0 {
+		return 0, fmt.Errorf("invalid mods %q", s)
+	}
+
+	var mods osuapi.Mods
+	for i := 0; i < len(s); i += 2 {
+		m := osuapi.ParseMods(s[i : i+2])
+		if m == 0 {
+			return 0, fmt.Errorf("unknown mod %q", s[i:i+2])
+		}
+		mods |= m
+	}
+	return normalizeMods(mods), nil
+}
+
+func parseMode(s string) (osuapi.Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "osu", "std", "standard", "0":
+		return osuapi.ModeOsu, nil
+	case "taiko", "1":
+		return osuapi.ModeTaiko, nil
+	case "fruits", "catch", "ctb", "2":
+		return osuapi.ModeCatchTheBeat, nil
+	case "mania", "3":
+		return osuapi.ModeOsuMania, nil
+	default:
+		return 0, fmt.Errorf("unknown mode %q", s)
+	}
+}
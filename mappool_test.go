@@ -0,0 +1,75 @@
+package banchogo
+
+import (
+	"testing"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestParseMappool(t *testing.T) {
+	yamlPool := `
+name: Test Pool
+mode: osu
+maps:
+  - slot: nm1
+    beatmap_id: 75
+  - slot: HD1
+    beatmap_id: 76
+  - slot: DT1
+    beatmap_id: 77
+    mods: NC
+  - slot: EZ1
+    beatmap_id: 78
+    mods: EZ NF
+  - slot: FM1
+    beatmap_id: 79
+  - slot: TB
+    beatmap_id: 80
+    mode: taiko
+`
+	jsonPool := `{"name": "Test Pool", "maps": [
+		{"slot": "NM1", "beatmap_id": 75},
+		{"slot": "HD1", "beatmap_id": 76},
+		{"slot": "DT1", "beatmap_id": 77, "mods": "NC"},
+		{"slot": "EZ1", "beatmap_id": 78, "mods": "EZNF"},
+		{"slot": "FM1", "beatmap_id": 79},
+		{"slot": "TB", "beatmap_id": 80, "mode": "taiko"}
+	]}`
+
+	expected := []PoolSlot{
+		{Slot: "NM1", BeatmapID: 75},
+		{Slot: "HD1", BeatmapID: 76, Mods: osuapi.ModHidden},
+		{Slot: "DT1", BeatmapID: 77, Mods: osuapi.ModNightcore | osuapi.ModDoubleTime},
+		{Slot: "EZ1", BeatmapID: 78, Mods: osuapi.ModEasy | osuapi.ModNoFail},
+		{Slot: "FM1", BeatmapID: 79, FreeMod: true},
+		{Slot: "TB", BeatmapID: 80, Mode: osuapi.ModeTaiko, FreeMod: true},
+	}
+
+	for format, data := range map[string]string{"yaml": yamlPool, "json": jsonPool} {
+		pool, err := ParseMappool([]byte(data), format)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if pool.Name != "Test Pool" || len(pool.Slots) != len(expected) {
+			t.Fatalf("%s: unexpected pool %+v", format, pool)
+		}
+		for i, s := range pool.Slots {
+			if s != expected[i] {
+				t.Errorf("%s: expected %+v, got %+v", format, expected[i], s)
+			}
+		}
+	}
+}
+
+func TestParseMappool_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate slot": `{"maps": [{"slot": "NM1", "beatmap_id": 1}, {"slot": "nm1", "beatmap_id": 2}]}`,
+		"missing id":     `{"maps": [{"slot": "NM1"}]}`,
+		"unknown mod":    `{"maps": [{"slot": "NM1", "beatmap_id": 1, "mods": "XX"}]}`,
+		"unknown mode":   `{"mode": "piano", "maps": []}`,
+	} {
+		if _, err := ParseMappool([]byte(data), "json"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package banchogo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ExportFormat int
+
+const (
+	ExportJSON ExportFormat = iota
+	ExportCSV
+	ExportJSONL
+)
+
+// MatchRecord is a single player score on a single map, a row of exported match results
+type MatchRecord struct {
+	LobbyID   int    `json:"lobby_id"`
+	BeatmapID int    `json:"beatmap_id"`
+	Beatmap   string `json:"beatmap"`
+	// Mods are map mods combined with player's own mods, e.g. "HDDT" or "NM"
+	Mods      string    `json:"mods"`
+	FreeMod   bool      `json:"freemod"`
+	Player    string    `json:"player"`
+	UserID    int       `json:"user_id,omitempty"`
+	Team      string    `json:"team,omitempty"`
+	Slot      int       `json:"slot"`
+	Score     int       `json:"score"`
+	Passed    bool      `json:"passed"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var matchRecordHeader = []string{
+	"lobby_id", "beatmap_id", "beatmap", "mods", "freemod", "player", "user_id", "team", "slot", "score", "passed", "timestamp",
+}
+
+// Records flattens the result into one record per score, timestamp is the time the map finished
+func (r *MatchResult) Records() []MatchRecord {
+	records := make([]MatchRecord, 0, len(r.Scores))
+	for _, s := range r.Scores {
+		rec := MatchRecord{
+			LobbyID:   r.LobbyID,
+			BeatmapID: r.BeatmapID,
+			Beatmap:   r.Beatmap,
+			Mods:      strings.ReplaceAll(formatMpMods(r.Mods|s.Mods), " ", ""),
+			FreeMod:   r.FreeMod,
+			UserID:    s.UserID,
+			Team:      string(s.Team),
+			Slot:      s.Slot,
+			Score:     s.Score,
+			Passed:    s.Passed,
+			Timestamp: r.FinishedAt,
+		}
+		if rec.Mods == "" {
+			rec.Mods = "NM"
+		}
+		if s.User != nil {
+			rec.Player = s.User.Name()
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func (rec MatchRecord) csvRow() []string {
+	return []string{
+		strconv.Itoa(rec.LobbyID),
+		strconv.Itoa(rec.BeatmapID),
+		rec.Beatmap,
+		rec.Mods,
+		strconv.FormatBool(rec.FreeMod),
+		rec.Player,
+		strconv.Itoa(rec.UserID),
+		rec.Team,
+		strconv.Itoa(rec.Slot),
+		strconv.Itoa(rec.Score),
+		strconv.FormatBool(rec.Passed),
+		rec.Timestamp.UTC().Format(time.RFC3339),
+	}
+}
+
+// ExportMatchResults writes records of all results to w.
+// JSON is a single array, CSV has a header row and JSONL has a record per line.
+func ExportMatchResults(w io.Writer, format ExportFormat, results ...*MatchResult) error {
+	if format == ExportJSON {
+		records := make([]MatchRecord, 0, len(results))
+		for _, r := range results {
+			records = append(records, r.Records()...)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	mw := NewMatchResultWriter(w, format)
+	for _, r := range results {
+		if err := mw.Write(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchResultWriter appends results to a writer as CSV or JSONL records.
+// JSON arrays can't be appended to, so ExportJSON is written as JSONL.
+type MatchResultWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format ExportFormat
+	csv    *csv.Writer
+
+	// NoHeader disables CSV header, useful when appending to an existing file
+	NoHeader    bool
+	wroteHeader bool
+}
+
+func NewMatchResultWriter(w io.Writer, format ExportFormat) *MatchResultWriter {
+	mw := &MatchResultWriter{w: w, format: format}
+	if format == ExportCSV {
+		mw.csv = csv.NewWriter(w)
+	}
+	return mw
+}
+
+// Write appends records of the result, it is safe for concurrent use
+func (mw *MatchResultWriter) Write(r *MatchResult) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	if mw.csv == nil {
+		enc := json.NewEncoder(mw.w)
+		for _, rec := range r.Records() {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !mw.NoHeader && !mw.wroteHeader {
+		if err := mw.csv.Write(matchRecordHeader); err != nil {
+			return err
+		}
+		mw.wroteHeader = true
+	}
+	for _, rec := range r.Records() {
+		if err := mw.csv.Write(rec.csvRow()); err != nil {
+			return err
+		}
+	}
+	mw.csv.Flush()
+	return mw.csv.Error()
+}
+
+// StreamMatchResults writes every finished map to w until the returned function is called.
+// Write errors are passed to onError if it isn't nil.
+func (l *Lobby) StreamMatchResults(w *MatchResultWriter, onError func(error)) func() {
+	return l.OnMatchFinished(func(r *MatchResult) {
+		if err := w.Write(r); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
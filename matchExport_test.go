@@ -0,0 +1,74 @@
+package banchogo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func testMatchResult(l *Lobby) *MatchResult {
+	return &MatchResult{
+		LobbyID:    l.Id,
+		BeatmapID:  75,
+		Beatmap:    "Kenji Ninuma - DISCO PRINCE [Normal]",
+		Mods:       osuapi.ModHidden,
+		FreeMod:    true,
+		FinishedAt: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Scores: []MatchScore{
+			{User: l.Client.GetUser("peppy"), UserID: 2, Slot: 1, Team: RedTeam, Score: 1000, Passed: true, Mods: osuapi.ModNightcore | osuapi.ModDoubleTime},
+			{User: l.Client.GetUser("Some Player"), Slot: 4, Team: BlueTeam, Score: 500},
+		},
+	}
+}
+
+func TestExportMatchResults(t *testing.T) {
+	l := newTestLobby()
+	r := testMatchResult(l)
+
+	var buf bytes.Buffer
+	if err := ExportMatchResults(&buf, ExportCSV, r); err != nil {
+		t.Fatal(err)
+	}
+	want := "lobby_id,beatmap_id,beatmap,mods,freemod,player,user_id,team,slot,score,passed,timestamp\n" +
+		"123,75,Kenji Ninuma - DISCO PRINCE [Normal],HDNC,true,peppy,2,Red,1,1000,true,2022-01-02T03:04:05Z\n" +
+		"123,75,Kenji Ninuma - DISCO PRINCE [Normal],HD,true,Some_Player,0,Blue,4,500,false,2022-01-02T03:04:05Z\n"
+	if buf.String() != want {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ExportMatchResults(&buf, ExportJSON, r, r); err != nil {
+		t.Fatal(err)
+	}
+	var records []MatchRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil || len(records) != 4 {
+		t.Fatalf("expected 4 json records, got %d (%v)", len(records), err)
+	}
+	if records[1].Player != "Some_Player" || records[1].Mods != "HD" || !records[1].Timestamp.Equal(r.FinishedAt) {
+		t.Errorf("unexpected record %+v", records[1])
+	}
+}
+
+func TestLobby_StreamMatchResults(t *testing.T) {
+	l := newTestLobby()
+
+	var buf bytes.Buffer
+	remove := l.StreamMatchResults(NewMatchResultWriter(&buf, ExportJSONL), func(err error) { t.Error(err) })
+
+	l.ev.Emit("MatchFinished", testMatchResult(l))
+	remove()
+	l.ev.Emit("MatchFinished", testMatchResult(l))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var rec MatchRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil || rec.Player != "peppy" || rec.UserID != 2 {
+		t.Errorf("unexpected record %+v (%v)", rec, err)
+	}
+}
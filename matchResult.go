@@ -0,0 +1,59 @@
+package banchogo
+
+import (
+	"time"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+// MatchResult is a result of a single played map in a lobby, emitted with MatchFinished event
+type MatchResult struct {
+	LobbyID int
+
+	BeatmapID    int
+	Beatmap      string
+	Mode         osuapi.Mode
+	Mods         osuapi.Mods
+	FreeMod      bool
+	TeamMode     TeamMode
+	WinCondition WinCondition
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Scores are in the same order as BanchoBot reported them
+	Scores []MatchScore
+}
+
+// TeamScores returns total score of each team, in head to head mode all scores are summed under NoTeam
+func (r *MatchResult) TeamScores() map[Team]int {
+	totals := make(map[Team]int, 2)
+	for _, s := range r.Scores {
+		totals[s.Team] += s.Score
+	}
+	return totals
+}
+
+// WinningTeam returns a team with the highest total score, NoTeam on a draw
+func (r *MatchResult) WinningTeam() Team {
+	totals := r.TeamScores()
+	switch {
+	case totals[RedTeam] > totals[BlueTeam]:
+		return RedTeam
+	case totals[BlueTeam] > totals[RedTeam]:
+		return BlueTeam
+	default:
+		return NoTeam
+	}
+}
+
+// TopScore returns the highest score in the match, nil if nobody finished the map
+func (r *MatchResult) TopScore() *MatchScore {
+	var top *MatchScore
+	for i := range r.Scores {
+		if top == nil || r.Scores[i].Score > top.Score {
+			top = &r.Scores[i]
+		}
+	}
+	return top
+}
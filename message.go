@@ -1,9 +1,5 @@
 package banchogo
 
-import "regexp"
-
-var actionRegex = regexp.MustCompile("^\x01ACTION( (.+)?)?\x01")
-
 type MessageSender interface {
 	Name() string
 	SendMessage(string) error
@@ -15,6 +11,10 @@ type Message interface {
 	Sender() MessageSender
 	Content() string
 	Action() string
+	IsAction() bool
+	CTCP() *CTCP
+	Links() []ChatLink
+	NowPlaying() *NowPlaying
 }
 
 type message struct {
@@ -29,10 +29,20 @@ func (b *message) Content() string {
 	return b.Message
 }
 
+// Action returns text of a /me action, empty string if the message isn't an action
 func (b *message) Action() string {
-	action := actionRegex.FindStringSubmatch(b.Content())
-	if action != nil {
-		return action[2]
+	if c := b.CTCP(); c != nil && c.Command == "ACTION" {
+		return c.Params
 	}
 	return ""
 }
+
+func (b *message) IsAction() bool {
+	c := b.CTCP()
+	return c != nil && c.Command == "ACTION"
+}
+
+// CTCP decodes the message as CTCP, returns nil if it's a regular message
+func (b *message) CTCP() *CTCP {
+	return DecodeCTCP(b.Content())
+}
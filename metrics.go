@@ -0,0 +1,70 @@
+package banchogo
+
+import (
+	"strings"
+	"time"
+)
+
+// Metrics receives client measurements, e.g. metrics.Collector exports them in Prometheus format.
+// Methods are called from client goroutines, so they must be safe for concurrent use and fast.
+type Metrics interface {
+	// InboundLine is called for every line received from the server, command is like "PRIVMSG", "PING" or "353"
+	InboundLine(command string)
+	// OutboundMessage is called for every sent message, target is MessageSender.Type(): "user", "channel" or "mp"
+	OutboundMessage(target string)
+	// RateLimitWait is how long an outgoing message waited for the rate limiter
+	RateLimitWait(d time.Duration)
+	// QueueChanged is called with +1 when a message is queued and with -1 when it's taken from the queue,
+	// deltas let metrics of several clients be summed up
+	QueueChanged(delta int)
+	// Reconnect is called when the client starts reconnecting after the connection was lost
+	Reconnect()
+	// BanchoCommand is called when a BanchoBot command like "stats" got a response,
+	// err is ErrMessageTimeout if BanchoBot didn't answer in time
+	BanchoCommand(command string, latency time.Duration, err error)
+	// HandlerDuration is how long an event handler of the client, its channels, users or lobbies took.
+	// Event names are lower case, e.g. "privatemessage".
+	HandlerDuration(event string, d time.Duration)
+}
+
+// observeHandler is used by event emitters of the client and its objects
+func (b *Client) observeHandler(event string, d time.Duration) {
+	if b.Metrics != nil {
+		b.Metrics.HandlerDuration(event, d)
+	}
+}
+
+// observeInboundLine reports the command of a raw line, lines without a prefix like "PING" start with the command
+func (b *Client) observeInboundLine(splits []string) {
+	if b.Metrics == nil || len(splits) == 0 {
+		return
+	}
+	command := splits[0]
+	if strings.HasPrefix(command, ":") && len(splits) > 1 {
+		command = splits[1]
+	}
+	b.Metrics.InboundLine(command)
+}
+
+// addQueued counts messages waiting in the queue and reports the change
+func (b *Client) addQueued(delta int) {
+	b.queued.Add(int32(delta))
+	if b.Metrics != nil {
+		b.Metrics.QueueChanged(delta)
+	}
+}
+
+// observeBanchoCommand passes the response through and reports how long BanchoBot took to answer
+func observeBanchoCommand[T any](b *Client, command string, resp <-chan T, errOf func(T) error) <-chan T {
+	if b.Metrics == nil {
+		return resp
+	}
+	start := time.Now()
+	observed := make(chan T, 1)
+	go func() {
+		r := <-resp
+		b.Metrics.BanchoCommand(command, time.Since(start), errOf(r))
+		observed <- r
+	}()
+	return observed
+}
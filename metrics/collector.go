@@ -0,0 +1,247 @@
+// Package metrics collects banchogo client measurements and serves them in Prometheus text format
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds, the same as Prometheus client defaults
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type CollectorOptions struct {
+	// Namespace is a prefix of metric names, "banchogo" if empty
+	Namespace string
+	// Buckets are histogram upper bounds in seconds, DefaultBuckets if empty
+	Buckets []float64
+}
+
+// Collector implements banchogo.Metrics and http.Handler which renders collected metrics
+// in Prometheus text format. One collector can be shared by several clients, e.g. a ClientPool:
+// counters and histograms are summed up and queue_depth is the number of messages waiting in all clients.
+//
+//	collector := metrics.NewCollector(metrics.CollectorOptions{})
+//	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Metrics: collector, ...})
+//	http.Handle("/metrics", collector)
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mu              sync.Mutex
+	inboundLines    map[string]float64
+	outbound        map[string]float64
+	rateLimitWait   *histogram
+	queueDepth      float64
+	reconnects      float64
+	commandLatency  map[string]*histogram
+	commandTimeouts map[string]float64
+	commandErrors   map[string]float64
+	handlerDuration map[string]*histogram
+}
+
+var _ banchogo.Metrics = (*Collector)(nil)
+
+func NewCollector(opt CollectorOptions) *Collector {
+	if opt.Namespace == "" {
+		opt.Namespace = "banchogo"
+	}
+	if len(opt.Buckets) == 0 {
+		opt.Buckets = DefaultBuckets
+	}
+	buckets := append([]float64(nil), opt.Buckets...)
+	sort.Float64s(buckets)
+
+	return &Collector{
+		namespace:       opt.Namespace,
+		buckets:         buckets,
+		inboundLines:    make(map[string]float64),
+		outbound:        make(map[string]float64),
+		rateLimitWait:   newHistogram(buckets),
+		commandLatency:  make(map[string]*histogram),
+		commandTimeouts: make(map[string]float64),
+		commandErrors:   make(map[string]float64),
+		handlerDuration: make(map[string]*histogram),
+	}
+}
+
+func (c *Collector) InboundLine(command string) {
+	c.mu.Lock()
+	c.inboundLines[command]++
+	c.mu.Unlock()
+}
+
+func (c *Collector) OutboundMessage(target string) {
+	c.mu.Lock()
+	c.outbound[target]++
+	c.mu.Unlock()
+}
+
+func (c *Collector) RateLimitWait(d time.Duration) {
+	c.mu.Lock()
+	c.rateLimitWait.observe(d.Seconds())
+	c.mu.Unlock()
+}
+
+func (c *Collector) QueueChanged(delta int) {
+	c.mu.Lock()
+	c.queueDepth += float64(delta)
+	c.mu.Unlock()
+}
+
+func (c *Collector) Reconnect() {
+	c.mu.Lock()
+	c.reconnects++
+	c.mu.Unlock()
+}
+
+// BanchoCommand observes latency of answered commands, timeouts and other errors are only counted
+func (c *Collector) BanchoCommand(command string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case errors.Is(err, banchogo.ErrMessageTimeout):
+		c.commandTimeouts[command]++
+	case err != nil:
+		c.commandErrors[command]++
+	default:
+		c.histogramFor(c.commandLatency, command).observe(latency.Seconds())
+	}
+}
+
+func (c *Collector) HandlerDuration(event string, d time.Duration) {
+	c.mu.Lock()
+	c.histogramFor(c.handlerDuration, event).observe(d.Seconds())
+	c.mu.Unlock()
+}
+
+func (c *Collector) histogramFor(m map[string]*histogram, label string) *histogram {
+	h, ok := m[label]
+	if !ok {
+		h = newHistogram(c.buckets)
+		m[label] = h
+	}
+	return h
+}
+
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes all metrics in Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	var sb strings.Builder
+	c.writeCounters(&sb, "inbound_lines_total", "Lines received from the server by IRC command.", "command", c.inboundLines)
+	c.writeCounters(&sb, "outbound_messages_total", "Messages sent by target type.", "target", c.outbound)
+	c.writeHistograms(&sb, "ratelimit_wait_seconds", "Time outgoing messages waited for the rate limiter.", "",
+		map[string]*histogram{"": c.rateLimitWait})
+	c.writeSingle(&sb, "queue_depth", "Messages waiting to be sent.", "gauge", c.queueDepth)
+	c.writeSingle(&sb, "reconnects_total", "Reconnects after the connection was lost.", "counter", c.reconnects)
+	c.writeHistograms(&sb, "bancho_command_duration_seconds", "Time BanchoBot took to answer a command.", "command", c.commandLatency)
+	c.writeCounters(&sb, "bancho_command_timeouts_total", "BanchoBot commands which weren't answered in time.", "command", c.commandTimeouts)
+	c.writeCounters(&sb, "bancho_command_errors_total", "BanchoBot commands which failed for other reasons.", "command", c.commandErrors)
+	c.writeHistograms(&sb, "handler_duration_seconds", "Execution time of event handlers by event.", "event", c.handlerDuration)
+	c.mu.Unlock()
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (c *Collector) header(sb *strings.Builder, name, help, kind string) string {
+	name = c.namespace + "_" + name
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return name
+}
+
+func (c *Collector) writeSingle(sb *strings.Builder, name, help, kind string, v float64) {
+	name = c.header(sb, name, help, kind)
+	fmt.Fprintf(sb, "%s %s\n", name, formatFloat(v))
+}
+
+func (c *Collector) writeCounters(sb *strings.Builder, name, help, label string, values map[string]float64) {
+	name = c.header(sb, name, help, "counter")
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(sb, "%s{%s} %s\n", name, formatLabel(label, k), formatFloat(values[k]))
+	}
+}
+
+// writeHistograms writes a histogram for every label value, label is empty for a histogram without labels
+func (c *Collector) writeHistograms(sb *strings.Builder, name, help, label string, values map[string]*histogram) {
+	name = c.header(sb, name, help, "histogram")
+	for _, k := range sortedKeys(values) {
+		h := values[k]
+		labels := ""
+		if label != "" {
+			labels = formatLabel(label, k) + ","
+		}
+		for i, le := range c.buckets {
+			fmt.Fprintf(sb, "%s_bucket{%sle=\"%s\"} %d\n", name, labels, formatFloat(le), h.counts[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+
+		labels = strings.TrimSuffix(labels, ",")
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		fmt.Fprintf(sb, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(sb, "%s_count%s %d\n", name, labels, h.count)
+	}
+}
+
+// histogram keeps cumulative bucket counts like Prometheus expects them
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabel(name, value string) string {
+	return name + `="` + labelValueReplacer.Replace(value) + `"`
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
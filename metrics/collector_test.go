@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/banchotest"
+	"go.uber.org/ratelimit"
+)
+
+func TestCollector_Client(t *testing.T) {
+	collector := NewCollector(CollectorOptions{})
+	// The unlimited limiter doesn't wait but still reports to metrics
+	b, conn := banchotest.NewClient(t, banchogo.ClientOptions{Metrics: collector, RateLimiter: ratelimit.NewUnlimited()},
+		"100\t:peppy!cho@ppy.sh PRIVMSG bot :hello",
+		":BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :bot rolls 7 point(s)")
+	b.OnPrivateMessage(func(*banchogo.PrivateMessage) {})
+	<-conn.Done()
+
+	if err := b.GetUser("peppy").SendMessage("hi"); err != nil {
+		t.Fatal(err)
+	}
+	collector.BanchoCommand("stats", 0, banchogo.ErrMessageTimeout)
+	collector.BanchoCommand("where", 0, errors.New("user offline"))
+	collector.BanchoCommand("roll", 300*time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, expected := range []string{
+		"# TYPE banchogo_inbound_lines_total counter\n",
+		`banchogo_inbound_lines_total{command="001"} 1` + "\n",
+		`banchogo_inbound_lines_total{command="PRIVMSG"} 2` + "\n",
+		`banchogo_outbound_messages_total{target="user"} 1` + "\n",
+		"banchogo_ratelimit_wait_seconds_count 1\n",
+		"banchogo_queue_depth 0\n",
+		"banchogo_reconnects_total 0\n",
+		`banchogo_bancho_command_duration_seconds_bucket{command="roll",le="0.25"} 0` + "\n",
+		`banchogo_bancho_command_duration_seconds_bucket{command="roll",le="0.5"} 1` + "\n",
+		`banchogo_bancho_command_duration_seconds_count{command="roll"} 1` + "\n",
+		`banchogo_bancho_command_timeouts_total{command="stats"} 1` + "\n",
+		`banchogo_bancho_command_errors_total{command="where"} 1` + "\n",
+		// Sent private messages are emitted too
+		`banchogo_handler_duration_seconds_count{event="privatemessage"} 2` + "\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in\n%s", expected, body)
+		}
+	}
+}
+
+func TestCollector_Labels(t *testing.T) {
+	c := NewCollector(CollectorOptions{Namespace: "bot", Buckets: []float64{1, 0.5}})
+	c.InboundLine("a\"b\\c\n")
+	// Two clients queue messages, one of them sends its message
+	c.QueueChanged(1)
+	c.QueueChanged(1)
+	c.QueueChanged(-1)
+	c.HandlerDuration("message", 2*time.Second)
+
+	var sb strings.Builder
+	c.WriteTo(&sb)
+	body := sb.String()
+	for _, expected := range []string{
+		`bot_inbound_lines_total{command="a\"b\\c\n"} 1` + "\n",
+		"bot_queue_depth 1\n",
+		`bot_handler_duration_seconds_bucket{event="message",le="0.5"} 0` + "\n" +
+			`bot_handler_duration_seconds_bucket{event="message",le="1"} 0` + "\n" +
+			`bot_handler_duration_seconds_bucket{event="message",le="+Inf"} 1` + "\n" +
+			`bot_handler_duration_seconds_sum{event="message"} 2` + "\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in\n%s", expected, body)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package banchogo
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+var (
+	nowPlayingRegex = regexp.MustCompile(`^is (listening to|playing|watching|editing) \[(https?://\S+) (.+)\](.*)$`)
+)
+
+// nowPlayingModes are game mode names used in mode flags like <Taiko>
+var nowPlayingModes = map[string]osuapi.Mode{
+	"taiko":        osuapi.ModeTaiko,
+	"catchthebeat": osuapi.ModeCatchTheBeat,
+	"osu!mania":    osuapi.ModeOsuMania,
+}
+
+// NowPlaying is a beatmap shared with /np command, e.g.
+// "is playing [https://osu.ppy.sh/beatmapsets/1#osu/75 Kenji Ninuma - DISCO PRINCE [Normal]] +Hidden"
+type NowPlaying struct {
+	// Verb is "listening to", "playing", "watching" or "editing"
+	Verb string
+
+	// BeatmapID is zero when user is listening to a beatmapset
+	BeatmapID    int
+	BeatmapsetID int
+	Mode         osuapi.Mode
+
+	Artist  string
+	Title   string
+	Version string
+
+	Mods osuapi.Mods
+}
+
+// ParseNowPlaying parses content of a /np action, like the one returned by Message.Action.
+// Returns nil if it's not a /np action.
+func ParseNowPlaying(action string) *NowPlaying {
+	m := nowPlayingRegex.FindStringSubmatch(action)
+	if m == nil {
+		return nil
+	}
+
+	np := &NowPlaying{Verb: m[1]}
+
+	switch ref := ParseLinkRef(m[2]).(type) {
+	case BeatmapRef:
+		np.BeatmapID, np.BeatmapsetID = ref.ID, ref.SetID
+		np.Mode, _ = ref.GameMode()
+	case BeatmapsetRef:
+		np.BeatmapsetID = ref.ID
+	default:
+		return nil
+	}
+
+	// Beatmapset links have no difficulty name, brackets belong to the title
+	title := m[3]
+	if i := versionStart(title); i > 0 && np.BeatmapID != 0 {
+		title, np.Version = title[:i-1], title[i+1:len(title)-1]
+	}
+	if artist, t, ok := strings.Cut(title, " - "); ok {
+		np.Artist, np.Title = artist, t
+	} else {
+		np.Title = title
+	}
+
+	// Flags are mods like +Hidden or -Easy and a mode like <Taiko>
+	for _, flag := range strings.Fields(m[4]) {
+		switch {
+		case strings.HasPrefix(flag, "+"), strings.HasPrefix(flag, "-"):
+			np.Mods |= banchoModNames[flag[1:]]
+		case strings.HasPrefix(flag, "<") && strings.HasSuffix(flag, ">"):
+			if mode, ok := nowPlayingModes[strings.ToLower(strings.Trim(flag, "<>"))]; ok {
+				np.Mode = mode
+			}
+		}
+	}
+
+	return np
+}
+
+// versionStart returns index of the bracket opening difficulty name at the end of "Artist - Title [Version]",
+// brackets inside the difficulty name are balanced. Returns -1 if there is no difficulty name.
+func versionStart(title string) int {
+	if !strings.HasSuffix(title, "]") {
+		return -1
+	}
+	depth := 0
+	for i := len(title) - 1; i > 0; i-- {
+		switch title[i] {
+		case ']':
+			depth++
+		case '[':
+			depth--
+			if depth == 0 {
+				if title[i-1] != ' ' {
+					return -1
+				}
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// NowPlaying parses the message as a /np action, returns nil if it isn't one
+func (b *message) NowPlaying() *NowPlaying {
+	if !b.IsAction() {
+		return nil
+	}
+	return ParseNowPlaying(b.Action())
+}
@@ -0,0 +1,65 @@
+package banchogo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestParseNowPlaying(t *testing.T) {
+	tests := []struct {
+		action string
+		want   *NowPlaying
+	}{
+		{
+			"is playing [https://osu.ppy.sh/beatmapsets/1#osu/75 Kenji Ninuma - DISCO PRINCE [Normal]] +Hidden +DoubleTime",
+			&NowPlaying{Verb: "playing", BeatmapID: 75, BeatmapsetID: 1, Mode: osuapi.ModeOsu, Artist: "Kenji Ninuma",
+				Title: "DISCO PRINCE", Version: "Normal", Mods: osuapi.ModHidden | osuapi.ModDoubleTime},
+		},
+		{
+			"is listening to [https://osu.ppy.sh/beatmapsets/1 Kenji Ninuma - DISCO PRINCE]",
+			&NowPlaying{Verb: "listening to", BeatmapsetID: 1, Artist: "Kenji Ninuma", Title: "DISCO PRINCE"},
+		},
+		{
+			"is watching [https://osu.ppy.sh/b/75 Some - Song [Hard [Extra]]] -Easy <Taiko>",
+			&NowPlaying{Verb: "watching", BeatmapID: 75, Mode: osuapi.ModeTaiko, Artist: "Some",
+				Title: "Song", Version: "Hard [Extra]", Mods: osuapi.ModEasy},
+		},
+		{
+			"is editing [https://osu.ppy.sh/beatmapsets/2#mania/3 A - B - C [4K]]",
+			&NowPlaying{Verb: "editing", BeatmapID: 3, BeatmapsetID: 2, Mode: osuapi.ModeOsuMania, Artist: "A",
+				Title: "B - C", Version: "4K"},
+		},
+		{"waves", nil},
+		{"is playing [https://example.com/ nothing]", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParseNowPlaying(tt.action); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseNowPlaying(%q) = %+v, want %+v", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestClient_OnNowPlaying(t *testing.T) {
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+
+	var got []*NowPlaying
+	var senders []string
+	b.OnNowPlaying(func(m Message, np *NowPlaying) {
+		got = append(got, np)
+		senders = append(senders, m.Sender().Name())
+	})
+
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG bot :\x01ACTION is listening to [https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE]\x01")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :\x01ACTION is playing [https://osu.ppy.sh/b/75 Kenji Ninuma - DISCO PRINCE [Normal]]\x01")
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :is playing [https://osu.ppy.sh/b/75 not an action]")
+
+	if len(got) != 2 || got[0].Verb != "listening to" || got[1].Version != "Normal" {
+		t.Fatalf("unexpected now playing events %+v", got)
+	}
+	if !reflect.DeepEqual(senders, []string{"peppy", "#osu"}) {
+		t.Errorf("unexpected senders %q", senders)
+	}
+}
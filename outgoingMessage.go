@@ -7,20 +7,29 @@ type OutgoingMessage struct {
 
 	Content string
 	C       chan error
+
+	// notice is sent as NOTICE instead of PRIVMSG, e.g. CTCP replies
+	notice bool
 }
 
 func newOutgoingBanchoMessage(client *Client, sender MessageSender, message string) *OutgoingMessage {
 	return &OutgoingMessage{
-		sender,
-		client,
-		message,
-		nil,
+		MessageSender: sender,
+		client:        client,
+		Content:       message,
 	}
 }
 
+func newOutgoingNotice(client *Client, sender MessageSender, message string) *OutgoingMessage {
+	o := newOutgoingBanchoMessage(client, sender, message)
+	o.notice = true
+	return o
+}
+
 func (o *OutgoingMessage) Send() error {
 	o.C = make(chan error, 1)
 	if o.client.IsConnected() {
+		o.client.addQueued(1)
 		o.client.messageQueue <- o
 	} else {
 		return ErrConnectionClosed
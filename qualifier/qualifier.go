@@ -0,0 +1,355 @@
+// Package qualifier runs tournament qualifiers: it creates a lobby, invites the roster,
+// plays every map of the pool in order and ranks players and teams by their scores.
+package qualifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+// maxLobbySize is how many players fit in a lobby
+const maxLobbySize = 16
+
+var (
+	ErrEmptyPool      = errors.New("mappool has no maps")
+	ErrEmptyRoster    = errors.New("roster has no players")
+	ErrRosterTooLarge = errors.New("roster doesn't fit in a lobby, split it across several runners")
+	ErrLobbyClosed    = errors.New("lobby was closed")
+)
+
+// Entrant is a qualifier participant, Team is empty for solo qualifiers
+type Entrant struct {
+	User *banchogo.User
+	Team string
+}
+
+type Options struct {
+	// Name is a lobby name, "Qualifiers" by default
+	Name string
+	Pool *banchogo.Mappool
+	// Roster is invited to the lobby, it can't have more than 16 players
+	Roster []Entrant
+
+	// Runs is how many times the whole pool is played, 1 by default.
+	// Only the best score of each player on a map is counted.
+	Runs int
+	// Method is used to sort standings, SumScore by default
+	Method       Method
+	WinCondition banchogo.WinCondition
+
+	// JoinTimeout is how long to wait for invited players before the first map, 5 minutes by default
+	JoinTimeout time.Duration
+	// ReadyTimeout is how long to wait for all players to ready up before starting a map, 2 minutes by default
+	ReadyTimeout time.Duration
+	// StartDelay is a "!mp start" countdown, 10 seconds by default
+	StartDelay time.Duration
+	// MapTimeout is how long a map may be played before Run gives up, 15 minutes by default
+	MapTimeout time.Duration
+
+	// CloseLobby closes the lobby when all maps are played
+	CloseLobby bool
+	// OnResult is called after every played map
+	OnResult func(Result)
+}
+
+// Result is a single played map
+type Result struct {
+	Run   int
+	Slot  banchogo.PoolSlot
+	Match *banchogo.MatchResult
+}
+
+// Runner plays qualifier maps in a tournament lobby
+type Runner struct {
+	client *banchogo.Client
+	opt    Options
+
+	mu      sync.Mutex
+	lobby   *banchogo.Lobby
+	results []Result
+}
+
+func New(client *banchogo.Client, opt Options) *Runner {
+	if opt.Name == "" {
+		opt.Name = "Qualifiers"
+	}
+	if opt.Runs <= 0 {
+		opt.Runs = 1
+	}
+	if opt.JoinTimeout <= 0 {
+		opt.JoinTimeout = 5 * time.Minute
+	}
+	if opt.ReadyTimeout <= 0 {
+		opt.ReadyTimeout = 2 * time.Minute
+	}
+	if opt.StartDelay <= 0 {
+		opt.StartDelay = 10 * time.Second
+	}
+	if opt.MapTimeout <= 0 {
+		opt.MapTimeout = 15 * time.Minute
+	}
+	return &Runner{client: client, opt: opt}
+}
+
+// Lobby returns the qualifier lobby, nil until Run creates it
+func (r *Runner) Lobby() *banchogo.Lobby {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lobby
+}
+
+// Results returns maps played so far
+func (r *Runner) Results() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Result(nil), r.results...)
+}
+
+// Standings ranks the roster by maps played so far
+func (r *Runner) Standings() *Standings {
+	return Rank(r.opt.Pool, r.opt.Roster, r.Results(), r.opt.Method)
+}
+
+// Run creates a lobby and plays the whole pool Runs times. Aborted maps are replayed.
+// It blocks until all maps are played, ctx is cancelled or the lobby is closed.
+func (r *Runner) Run(ctx context.Context) (*Standings, error) {
+	if r.opt.Pool == nil || len(r.opt.Pool.Slots) == 0 {
+		return nil, ErrEmptyPool
+	}
+	if len(r.opt.Roster) == 0 {
+		return nil, ErrEmptyRoster
+	}
+	if len(r.opt.Roster) > maxLobbySize {
+		return nil, ErrRosterTooLarge
+	}
+
+	resp := <-r.client.CreateLobby(r.opt.Name)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	l := resp.Lobby
+
+	r.mu.Lock()
+	r.lobby = l
+	r.mu.Unlock()
+
+	if err := l.SetSettings(banchogo.HeadToHead, r.opt.WinCondition, len(r.opt.Roster)); err != nil {
+		return nil, err
+	}
+	for _, e := range r.opt.Roster {
+		if err := l.Invite(e.User); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.waitPlayers(ctx, l); err != nil {
+		return nil, err
+	}
+
+	for run := 1; run <= r.opt.Runs; run++ {
+		for _, slot := range r.opt.Pool.Slots {
+			result, err := r.play(ctx, l, slot)
+			for errors.Is(err, errAborted) {
+				result, err = r.play(ctx, l, slot)
+			}
+			if err != nil {
+				return r.Standings(), err
+			}
+
+			res := Result{Run: run, Slot: slot, Match: result}
+			r.mu.Lock()
+			r.results = append(r.results, res)
+			r.mu.Unlock()
+
+			if r.opt.OnResult != nil {
+				r.opt.OnResult(res)
+			}
+		}
+	}
+
+	if r.opt.CloseLobby {
+		if err := l.Close(); err != nil {
+			return r.Standings(), err
+		}
+	}
+
+	return r.Standings(), nil
+}
+
+var errAborted = errors.New("match aborted")
+
+// waitPlayers waits until the whole roster joins the lobby or JoinTimeout passes
+func (r *Runner) waitPlayers(ctx context.Context, l *banchogo.Lobby) error {
+	joined := make(chan struct{}, 1)
+	closed := make(chan struct{}, 1)
+	defer l.OnPlayerJoined(func(*banchogo.LobbyPlayer) { notify(joined) })()
+	defer l.OnceClosed(func() { notify(closed) })()
+
+	timeout := time.After(r.opt.JoinTimeout)
+	for {
+		all := true
+		for _, e := range r.opt.Roster {
+			if l.Player(e.User) == nil {
+				all = false
+				break
+			}
+		}
+		if all {
+			return nil
+		}
+
+		select {
+		case <-joined:
+		case <-timeout:
+			return nil
+		case <-closed:
+			return ErrLobbyClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// play applies the slot, waits for players to ready up and returns the match result
+func (r *Runner) play(ctx context.Context, l *banchogo.Lobby, slot banchogo.PoolSlot) (*banchogo.MatchResult, error) {
+	ready := make(chan struct{}, 1)
+	aborted := make(chan struct{}, 1)
+	closed := make(chan struct{}, 1)
+	finished := make(chan *banchogo.MatchResult, 1)
+
+	defer l.OnAllPlayersReady(func() { notify(ready) })()
+	defer l.OnMatchAborted(func() { notify(aborted) })()
+	defer l.OnceClosed(func() { notify(closed) })()
+	defer l.OnMatchFinished(func(m *banchogo.MatchResult) {
+		select {
+		case finished <- m:
+		default:
+		}
+	})()
+
+	select {
+	case err := <-l.ApplyPoolSlot(slot):
+		if err != nil {
+			return nil, err
+		}
+	case <-closed:
+		return nil, ErrLobbyClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// Players are unreadied by the map change, so earlier notifications are stale
+	select {
+	case <-ready:
+	default:
+	}
+
+	select {
+	case <-ready:
+	case <-time.After(r.opt.ReadyTimeout):
+	case <-closed:
+		return nil, ErrLobbyClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := l.Start(r.opt.StartDelay); err != nil {
+		return nil, err
+	}
+
+	select {
+	case m := <-finished:
+		return m, nil
+	case <-aborted:
+		return nil, errAborted
+	case <-time.After(r.opt.StartDelay + r.opt.MapTimeout):
+		return nil, banchogo.ErrMessageTimeout
+	case <-closed:
+		return nil, ErrLobbyClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func notify(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// Rank computes qualifier standings from played maps. Scores of users outside the roster are ignored.
+// Team score on a map is the sum of its members' scores.
+func Rank(pool *banchogo.Mappool, roster []Entrant, results []Result, method Method) *Standings {
+	var slots []string
+	if pool != nil {
+		for _, s := range pool.Slots {
+			slots = append(slots, s.Slot)
+		}
+	}
+
+	players := make([]string, 0, len(roster))
+	teamOf := make(map[*banchogo.User]string, len(roster))
+	var teams []string
+	for _, e := range roster {
+		players = append(players, e.User.Name())
+		teamOf[e.User] = e.Team
+		if e.Team != "" && !contains(teams, e.Team) {
+			teams = append(teams, e.Team)
+		}
+	}
+
+	// Only the best score from all runs counts
+	playerScores := make(map[string]map[string]int, len(players))
+	for _, res := range results {
+		if res.Match == nil {
+			continue
+		}
+		for _, s := range res.Match.Scores {
+			if _, ok := teamOf[s.User]; !ok {
+				continue
+			}
+			name := s.User.Name()
+			if playerScores[name] == nil {
+				playerScores[name] = make(map[string]int, len(slots))
+			}
+			if s.Score > playerScores[name][res.Slot.Slot] {
+				playerScores[name][res.Slot.Slot] = s.Score
+			}
+		}
+	}
+
+	standings := &Standings{Players: rank(players, slots, playerScores, method)}
+	if len(teams) == 0 {
+		return standings
+	}
+
+	teamScores := make(map[string]map[string]int, len(teams))
+	for _, e := range roster {
+		if e.Team == "" {
+			continue
+		}
+		if teamScores[e.Team] == nil {
+			teamScores[e.Team] = make(map[string]int, len(slots))
+		}
+		for slot, score := range playerScores[e.User.Name()] {
+			teamScores[e.Team][slot] += score
+		}
+	}
+	standings.Teams = rank(teams, slots, teamScores, method)
+
+	return standings
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package qualifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/banchotest"
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestRunner_Run(t *testing.T) {
+	mp := func(offset int, line string) string {
+		return fmt.Sprintf("%d\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :%s", offset, line)
+	}
+	client, conn := banchotest.NewClient(t, banchogo.ClientOptions{},
+		"200\t:BanchoBot!cho@ppy.sh PRIVMSG bot :Created the tournament match https://osu.ppy.sh/mp/1 Quals",
+		mp(400, "a joined in slot 1."),
+		mp(400, "b joined in slot 2."),
+		// The first attempt of NM1 is aborted and played again
+		mp(600, "Changed beatmap to https://osu.ppy.sh/b/1 Map One"),
+		mp(600, "Disabled all mods, disabled FreeMod"),
+		mp(800, "The match has started!"),
+		mp(900, "Aborted the match"),
+		mp(1100, "Changed beatmap to https://osu.ppy.sh/b/1 Map One"),
+		mp(1100, "Disabled all mods, disabled FreeMod"),
+		mp(1300, "The match has started!"),
+		mp(1400, "a finished playing (Score: 1000, PASSED)."),
+		mp(1400, "b finished playing (Score: 2000, PASSED)."),
+		mp(1400, "The match has finished!"),
+		mp(1600, "Changed beatmap to https://osu.ppy.sh/b/2 Map Two"),
+		mp(1600, "Enabled Hidden, disabled FreeMod"),
+		mp(1800, "The match has started!"),
+		mp(1900, "a finished playing (Score: 3000, PASSED)."),
+		mp(1900, "b finished playing (Score: 500, FAILED)."),
+		mp(1900, "The match has finished!"))
+
+	r := New(client, Options{
+		Name: "Quals",
+		Pool: &banchogo.Mappool{Slots: []banchogo.PoolSlot{
+			{Slot: "NM1", BeatmapID: 1},
+			{Slot: "HD1", BeatmapID: 2, Mods: osuapi.ModHidden},
+		}},
+		Roster:       []Entrant{{User: client.GetUser("a")}, {User: client.GetUser("b")}},
+		ReadyTimeout: 10 * time.Millisecond,
+		StartDelay:   time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := r.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results := r.Results(); len(results) != 2 || results[0].Slot.Slot != "NM1" || results[1].Slot.Slot != "HD1" {
+		t.Errorf("aborted map should be played again and counted once, got %+v", results)
+	}
+	if len(s.Players) != 2 || s.Players[0].Name != "a" || s.Players[0].TotalScore != 4000 || s.Players[1].TotalScore != 2500 {
+		t.Errorf("unexpected standings %+v", s.Players)
+	}
+
+	// Every attempt applies the slot again
+	want := []string{"PRIVMSG BanchoBot :!mp make Quals", "PRIVMSG #mp_1 :!mp set 0 0 2",
+		"PRIVMSG #mp_1 :!mp invite a", "PRIVMSG #mp_1 :!mp invite b"}
+	for _, slot := range []string{"map 1 0|mods None", "map 1 0|mods None", "map 2 0|mods HD"} {
+		for _, cmd := range append(strings.Split(slot, "|"), "start 0") {
+			want = append(want, "PRIVMSG #mp_1 :!mp "+cmd)
+		}
+	}
+	if sent := conn.Sent()[3:]; !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+}
+
+func TestRunner_RosterTooLarge(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "ref"})
+	var roster []Entrant
+	for i := 0; i <= maxLobbySize; i++ {
+		roster = append(roster, Entrant{User: client.GetUser(fmt.Sprint("player", i))})
+	}
+	r := New(client, Options{Pool: &banchogo.Mappool{Slots: []banchogo.PoolSlot{{Slot: "NM1", BeatmapID: 1}}}, Roster: roster})
+	if _, err := r.Run(context.Background()); !errors.Is(err, ErrRosterTooLarge) {
+		t.Errorf("expected ErrRosterTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package qualifier
+
+import (
+	"math"
+	"sort"
+)
+
+// Method is a way to rank qualifier entrants
+type Method int
+
+const (
+	// SumScore ranks by the sum of scores on all maps
+	SumScore Method = iota
+	// AverageRank ranks by the average placement on each map, lower is better
+	AverageRank
+	// ZSum ranks by the sum of z-scores on each map, so every map has the same weight
+	ZSum
+)
+
+// Standing is a qualifier result of a single player or team.
+// Entrants who didn't play a map get a zero score on it.
+type Standing struct {
+	Name string
+	// Rank is a final placement by the chosen Method, starting from 1
+	Rank int
+
+	TotalScore  int64
+	AverageRank float64
+	ZSum        float64
+
+	// MapScores and MapRanks are keyed by slot name
+	MapScores map[string]int
+	MapRanks  map[string]int
+}
+
+type Standings struct {
+	Players []Standing
+	// Teams is empty if roster has no teams
+	Teams []Standing
+}
+
+// rank computes standings of entrants from their scores keyed by entrant and slot
+func rank(entrants []string, slots []string, scores map[string]map[string]int, method Method) []Standing {
+	standings := make([]Standing, len(entrants))
+	for i, name := range entrants {
+		standings[i] = Standing{
+			Name:      name,
+			MapScores: make(map[string]int, len(slots)),
+			MapRanks:  make(map[string]int, len(slots)),
+		}
+	}
+
+	for _, slot := range slots {
+		values := make([]float64, len(entrants))
+		for i, name := range entrants {
+			score := scores[name][slot]
+			standings[i].MapScores[slot] = score
+			standings[i].TotalScore += int64(score)
+			values[i] = float64(score)
+		}
+
+		mean, stddev := meanStddev(values)
+		for i := range standings {
+			if stddev > 0 {
+				standings[i].ZSum += (values[i] - mean) / stddev
+			}
+
+			// Entrants with equal scores share the same placement
+			place := 1
+			for j := range values {
+				if values[j] > values[i] {
+					place++
+				}
+			}
+			standings[i].MapRanks[slot] = place
+			standings[i].AverageRank += float64(place)
+		}
+	}
+
+	if len(slots) > 0 {
+		for i := range standings {
+			standings[i].AverageRank /= float64(len(slots))
+		}
+	}
+
+	better := func(a, b Standing) bool {
+		switch method {
+		case AverageRank:
+			return a.AverageRank < b.AverageRank
+		case ZSum:
+			return a.ZSum > b.ZSum
+		default:
+			return a.TotalScore > b.TotalScore
+		}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return better(standings[i], standings[j])
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && !better(standings[i-1], standings[i]) {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+func meanStddev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
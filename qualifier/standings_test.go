@@ -0,0 +1,85 @@
+package qualifier
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robloxxa/banchogo"
+)
+
+func TestRank(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "ref"})
+	a, b, c, outsider := client.GetUser("a"), client.GetUser("b"), client.GetUser("c"), client.GetUser("outsider")
+
+	pool := &banchogo.Mappool{Slots: []banchogo.PoolSlot{
+		{Slot: "NM1", BeatmapID: 1},
+		{Slot: "NM2", BeatmapID: 2},
+	}}
+	roster := []Entrant{{User: a, Team: "X"}, {User: b, Team: "X"}, {User: c, Team: "Y"}}
+
+	result := func(run int, slot int, scores ...banchogo.MatchScore) Result {
+		return Result{Run: run, Slot: pool.Slots[slot], Match: &banchogo.MatchResult{Scores: scores}}
+	}
+	results := []Result{
+		result(1, 0, banchogo.MatchScore{User: a, Score: 900}, banchogo.MatchScore{User: b, Score: 600},
+			banchogo.MatchScore{User: c, Score: 300}, banchogo.MatchScore{User: outsider, Score: 1000}),
+		result(1, 1, banchogo.MatchScore{User: a, Score: 100}, banchogo.MatchScore{User: c, Score: 500}),
+		// Only the best run counts
+		result(2, 0, banchogo.MatchScore{User: c, Score: 200}),
+		result(2, 1, banchogo.MatchScore{User: b, Score: 700}),
+	}
+
+	s := Rank(pool, roster, results, SumScore)
+	if len(s.Players) != 3 {
+		t.Fatalf("expected 3 players, got %d", len(s.Players))
+	}
+
+	want := []struct {
+		name  string
+		total int64
+		avg   float64
+	}{{"b", 1300, 1.5}, {"a", 1000, 2}, {"c", 800, 2.5}}
+	for i, w := range want {
+		p := s.Players[i]
+		if p.Name != w.name || p.Rank != i+1 || p.TotalScore != w.total || p.AverageRank != w.avg {
+			t.Errorf("unexpected standing #%d: %+v", i+1, p)
+		}
+	}
+
+	if s.Players[2].MapScores["NM1"] != 300 {
+		t.Errorf("expected the best score of c on NM1, got %d", s.Players[2].MapScores["NM1"])
+	}
+
+	var zsum float64
+	for _, p := range s.Players {
+		zsum += p.ZSum
+	}
+	if math.Abs(zsum) > 1e-9 {
+		t.Errorf("z-scores should sum up to zero, got %f", zsum)
+	}
+
+	if len(s.Teams) != 2 || s.Teams[0].Name != "X" || s.Teams[0].TotalScore != 2300 || s.Teams[1].TotalScore != 800 {
+		t.Errorf("unexpected team standings %+v", s.Teams)
+	}
+
+	s = Rank(pool, roster, results, AverageRank)
+	if s.Players[0].Name != "b" || s.Players[2].Name != "c" {
+		t.Errorf("unexpected average rank order %+v", s.Players)
+	}
+}
+
+func TestRank_Ties(t *testing.T) {
+	standings := rank([]string{"a", "b", "c"}, []string{"NM1"}, map[string]map[string]int{
+		"a": {"NM1": 500},
+		"b": {"NM1": 500},
+		"c": {"NM1": 100},
+	}, SumScore)
+
+	ranks := []int{standings[0].Rank, standings[1].Rank, standings[2].Rank}
+	if ranks[0] != 1 || ranks[1] != 1 || ranks[2] != 3 {
+		t.Errorf("expected ranks 1 1 3, got %v", ranks)
+	}
+	if standings[2].MapRanks["NM1"] != 3 {
+		t.Errorf("expected map rank 3, got %d", standings[2].MapRanks["NM1"])
+	}
+}
@@ -0,0 +1,167 @@
+package banchogo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRollMax is the upper bound of "!roll" without an argument
+const DefaultRollMax = 100
+
+// rollAnswerTimeout is how long a "!roll" waits for BanchoBot's answer, older rolls are forgotten
+const rollAnswerTimeout = 10 * time.Second
+
+var (
+	rollCommandRegex = regexp.MustCompile(`^!roll(?: +(\d+))?(?: |$)`)
+	rollResultRegex  = regexp.MustCompile(`^(.+) rolls (\d+) point\(s\)$`)
+)
+
+// RollResult is BanchoBot's answer to "!roll"
+type RollResult struct {
+	User  *User
+	Value int
+	// Max is the upper bound the user asked for, DefaultRollMax if the "!roll" command wasn't seen
+	Max int
+	// Channel is nil for rolls in private messages with BanchoBot
+	Channel *Channel
+}
+
+// pendingRoll is a "!roll" command waiting for BanchoBot's answer
+type pendingRoll struct {
+	max  int
+	sent time.Time
+}
+
+type RollResponse struct {
+	Result *RollResult
+	Error  error
+}
+
+// rollKey identifies a pending roll, BanchoBot answers in the same channel and names the user
+func rollKey(channel *Channel, user *User) string {
+	target := ""
+	if channel != nil {
+		target = strings.ToLower(channel.Name())
+	}
+	return target + " " + strings.ToLower(user.Name())
+}
+
+// trackOutgoingRoll tracks "!roll" sent by the client, it's called before the message is sent,
+// so BanchoBot can't answer before the roll is known
+func (b *Client) trackOutgoingRoll(target MessageSender, content string) {
+	switch t := target.(type) {
+	case *User:
+		if strings.EqualFold(t.Name(), "BanchoBot") {
+			b.trackRoll(nil, b.GetSelf(), content)
+		}
+	case *Channel:
+		b.trackRoll(t, b.GetSelf(), content)
+	case *Lobby:
+		b.trackRoll(t.Channel, b.GetSelf(), content)
+	}
+}
+
+// trackRoll remembers the upper bound of a "!roll" command and turns BanchoBot's answers into Roll events.
+// channel is nil for private messages with BanchoBot.
+func (b *Client) trackRoll(channel *Channel, user *User, content string) {
+	// Other users can roll only in channels, in private messages BanchoBot answers to the client
+	if r := rollCommandRegex.FindStringSubmatch(content); r != nil && (channel != nil || user.IsClient()) {
+		max := DefaultRollMax
+		if v, err := strconv.Atoi(r[1]); err == nil && v > 0 {
+			max = v
+		}
+		now := time.Now()
+		b.rollMu.Lock()
+		if b.pendingRolls == nil {
+			b.pendingRolls = make(map[string]pendingRoll)
+		}
+		// BanchoBot doesn't answer some rolls, e.g. from silenced users, so stale rolls are dropped
+		for key, roll := range b.pendingRolls {
+			if now.Sub(roll.sent) > rollAnswerTimeout {
+				delete(b.pendingRolls, key)
+			}
+		}
+		b.pendingRolls[rollKey(channel, user)] = pendingRoll{max: max, sent: now}
+		b.rollMu.Unlock()
+		return
+	}
+
+	if !strings.EqualFold(user.Name(), "BanchoBot") {
+		return
+	}
+	r := rollResultRegex.FindStringSubmatch(content)
+	if r == nil {
+		return
+	}
+
+	result := &RollResult{User: b.GetUser(r[1]), Channel: channel, Max: DefaultRollMax}
+	result.Value, _ = strconv.Atoi(r[2])
+
+	key := rollKey(channel, result.User)
+	b.rollMu.Lock()
+	if roll, ok := b.pendingRolls[key]; ok && time.Since(roll.sent) <= rollAnswerTimeout {
+		result.Max = roll.max
+	}
+	delete(b.pendingRolls, key)
+	b.rollMu.Unlock()
+
+	b.ev.Emit("Roll", result)
+	if channel != nil {
+		if l, ok := b.Lobbies.Load(channel.Name()); ok {
+			l.ev.Emit("Roll", result)
+		}
+	}
+}
+
+// Roll rolls a number from 1 to max in private messages with BanchoBot, max less than 1 means DefaultRollMax
+func (b *Client) Roll(max int) <-chan RollResponse {
+	return b.roll(b.GetUser("BanchoBot"), nil, max)
+}
+
+// Roll rolls a number from 1 to max in the lobby chat, max less than 1 means DefaultRollMax
+func (l *Lobby) Roll(max int) <-chan RollResponse {
+	return l.Client.roll(l, l.Channel, max)
+}
+
+func (b *Client) roll(target MessageSender, channel *Channel, max int) <-chan RollResponse {
+	var clearEvent func()
+
+	resp := make(chan RollResponse, 1)
+
+	timer := time.AfterFunc(rollAnswerTimeout, func() {
+		select {
+		case resp <- RollResponse{Error: ErrMessageTimeout}:
+			clearEvent()
+		default:
+		}
+	})
+
+	clearEvent = b.OnRoll(func(r *RollResult) {
+		if r.Channel != channel || !r.User.IsClient() {
+			return
+		}
+		select {
+		case resp <- RollResponse{Result: r}:
+			timer.Stop()
+			clearEvent()
+		default:
+		}
+	})
+
+	command := "!roll"
+	if max > 0 {
+		command += " " + strconv.Itoa(max)
+	}
+	if err := target.SendMessage(command); err != nil {
+		select {
+		case resp <- RollResponse{Error: err}:
+			timer.Stop()
+			clearEvent()
+		default:
+		}
+	}
+
+	return observeBanchoCommand(b, "roll", resp, func(r RollResponse) error { return r.Error })
+}
@@ -0,0 +1,64 @@
+package banchogo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClient_RollEvents(t *testing.T) {
+	b := NewBanchoClient(ClientOptions{Username: "bot"})
+	lobby := b.GetLobby(1)
+
+	var results []*RollResult
+	b.OnRoll(func(r *RollResult) { results = append(results, r) })
+	lobbyRolls := 0
+	lobby.OnRoll(func(*RollResult) { lobbyRolls++ })
+
+	// Captains roll at once, BanchoBot answers in a different order
+	feedLine(b, ":Captain_A!cho@ppy.sh PRIVMSG #mp_1 :!roll 1000")
+	feedLine(b, ":Captain_B!cho@ppy.sh PRIVMSG #mp_1 :!roll")
+	feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Captain B rolls 42 point(s)")
+	feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Captain A rolls 777 point(s)")
+	feedLine(b, ":BanchoBot!cho@ppy.sh PRIVMSG #osu :peppy rolls 1 point(s)")
+
+	if len(results) != 3 || lobbyRolls != 2 {
+		t.Fatalf("expected 3 rolls and 2 in the lobby, got %d and %d", len(results), lobbyRolls)
+	}
+	if r := results[0]; r.User.Name() != "Captain_B" || r.Value != 42 || r.Max != 100 || r.Channel != lobby.Channel {
+		t.Errorf("unexpected roll %+v", r)
+	}
+	if r := results[1]; r.User.Name() != "Captain_A" || r.Value != 777 || r.Max != 1000 {
+		t.Errorf("unexpected roll %+v", r)
+	}
+	if r := results[2]; r.User.Name() != "peppy" || r.Channel.Name() != "#osu" {
+		t.Errorf("unexpected roll %+v", r)
+	}
+
+	// Rolls BanchoBot never answered are forgotten
+	feedLine(b, ":Silenced!cho@ppy.sh PRIVMSG #osu :!roll 5")
+	osu, _ := b.GetChannel("#osu")
+	stale := rollKey(osu, b.GetUser("Silenced"))
+	b.pendingRolls[stale] = pendingRoll{max: 5, sent: time.Now().Add(-time.Minute)}
+	feedLine(b, ":peppy!cho@ppy.sh PRIVMSG #osu :!roll")
+	if _, ok := b.pendingRolls[stale]; ok || len(b.pendingRolls) != 1 {
+		t.Errorf("stale roll should be dropped, pending %v", b.pendingRolls)
+	}
+}
+
+func TestLobby_Roll(t *testing.T) {
+	b, conn := newTestClient(t,
+		"100\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :peppy rolls 3 point(s)",
+		"100\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :bot rolls 7 point(s)")
+
+	select {
+	case resp := <-b.GetLobby(1).Roll(10):
+		if resp.Error != nil || resp.Result.Value != 7 || resp.Result.Max != 10 {
+			t.Errorf("unexpected roll response %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("roll wasn't answered")
+	}
+	if sent := conn.Sent()[3:]; len(sent) != 1 || sent[0] != "PRIVMSG #mp_1 :!roll 10" {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/robloxxa/banchogo"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	ErrMissingArgument   = errors.New("missing argument")
+	ErrNotBeatmap        = errors.New("not a beatmap link")
+)
+
+type ArgType int
+
+const (
+	// ArgString is a single word or "quoted text"
+	ArgString ArgType = iota
+	ArgInt
+	// ArgUser is an osu! username, spaces must be replaced with underscores or quoted
+	ArgUser
+	// ArgBeatmap is a beatmap link or plain beatmap ID
+	ArgBeatmap
+	// ArgRest consumes the rest of the line as is
+	ArgRest
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "int"
+	case ArgUser:
+		return "user"
+	case ArgBeatmap:
+		return "beatmap"
+	case ArgRest:
+		return "text"
+	default:
+		return "string"
+	}
+}
+
+// Arg describes a single command argument
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+}
+
+func (a Arg) usage() string {
+	s := a.Name
+	if a.Type != ArgString {
+		s += ":" + a.Type.String()
+	}
+	if a.Type == ArgRest {
+		s += "..."
+	}
+	if a.Optional {
+		return "[" + s + "]"
+	}
+	return "<" + s + ">"
+}
+
+// ArgError is returned when argument is missing or can't be parsed
+type ArgError struct {
+	Arg   Arg
+	Value string
+	Err   error
+}
+
+func (e *ArgError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Arg.Name, e.Err)
+	}
+	return fmt.Sprintf("%s: %q is not a valid %s", e.Arg.Name, e.Value, e.Arg.Type)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// Beatmap is a beatmap reference parsed from ArgBeatmap argument
+type Beatmap struct {
+	ID    int
+	SetID int
+	// Mode is a ruleset name from the link ("osu", "taiko", "fruits", "mania"), empty if unknown
+	Mode string
+}
+
+func parseBeatmap(s string) (Beatmap, error) {
+	if id, err := strconv.Atoi(s); err == nil && id > 0 {
+		return Beatmap{ID: id}, nil
+	}
+
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		s = "https://" + s
+	}
+	if ref, ok := banchogo.ParseLinkRef(s).(banchogo.BeatmapRef); ok {
+		return Beatmap(ref), nil
+	}
+
+	return Beatmap{}, ErrNotBeatmap
+}
+
+type token struct {
+	value string
+	start int
+}
+
+// tokenize splits s into words. Words can be grouped with double quotes,
+// backslash escapes a quote inside quoted word.
+func tokenize(s string) ([]token, error) {
+	var (
+		tokens  []token
+		current strings.Builder
+		start   = -1
+		quoted  bool
+		escaped bool
+	)
+
+	for i, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			if start == -1 {
+				start = i
+			}
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if start != -1 {
+				tokens = append(tokens, token{current.String(), start})
+				current.Reset()
+				start = -1
+			}
+		default:
+			if start == -1 {
+				start = i
+			}
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, ErrUnterminatedQuote
+	}
+	if start != -1 {
+		tokens = append(tokens, token{current.String(), start})
+	}
+	return tokens, nil
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	"strings"
+)
+
+// Scope restricts where a command can be invoked from.
+// Zero value means the command is available everywhere.
+type Scope int
+
+const (
+	ScopePrivate Scope = 1 << iota
+	ScopeChannel
+	ScopeMultiplayer
+
+	Anywhere        = ScopePrivate | ScopeChannel | ScopeMultiplayer
+	PrivateOnly     = ScopePrivate
+	ChannelOnly     = ScopeChannel | ScopeMultiplayer
+	MultiplayerOnly = ScopeMultiplayer
+)
+
+// Allows reports whether command with this scope can be invoked from scope s.
+func (sc Scope) Allows(s Scope) bool {
+	if sc == 0 {
+		return true
+	}
+	return sc&s != 0
+}
+
+// Handler is a function that handles command invocation.
+// Returned error is passed to Options.OnError.
+type Handler func(ctx *Context) error
+
+// Command is a bot command registered in Router
+type Command struct {
+	// Name of a command without prefix, e.g. "roll"
+	Name    string
+	Aliases []string
+
+	// Description is a short text shown in help output
+	Description string
+
+	// Args are parsed in order. Only the last argument can be ArgRest.
+	Args  []Arg
+	Scope Scope
+
+	// Permission is a minimal permission level required to invoke the command
+	Permission Permission
+	Cooldown   Cooldown
+
+	// Hidden commands are not shown in help output
+	Hidden bool
+
+	Handler Handler
+}
+
+// Usage returns command usage string, e.g. "!mp <id:int> [name]"
+func (c *Command) Usage(prefix string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(c.Name)
+	for _, a := range c.Args {
+		sb.WriteByte(' ')
+		sb.WriteString(a.usage())
+	}
+	return sb.String()
+}
+
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/robloxxa/banchogo"
+)
+
+// Context is passed to command Handler and holds everything about invocation
+type Context struct {
+	Router *Router
+	Client *banchogo.Client
+
+	// User who invoked the command
+	User *banchogo.User
+	// Sender is where the command came from: *banchogo.User for private messages
+	// or *banchogo.Channel for channel messages. Replies are sent through it.
+	Sender banchogo.MessageSender
+
+	Command *Command
+	Prefix  string
+	// Invoked is a command name or alias used to invoke the command
+	Invoked string
+	// Content is a full message content
+	Content string
+	Scope   Scope
+
+	args map[string]interface{}
+}
+
+// Reply sends a message to where the command came from
+func (c *Context) Reply(message string) error {
+	return c.Sender.SendMessage(message)
+}
+
+func (c *Context) Replyf(format string, a ...any) error {
+	return c.Reply(fmt.Sprintf(format, a...))
+}
+
+// Channel returns a channel where command was invoked, nil for private messages
+func (c *Context) Channel() *banchogo.Channel {
+	channel, _ := c.Sender.(*banchogo.Channel)
+	return channel
+}
+
+func (c *Context) IsPrivate() bool {
+	return c.Scope == ScopePrivate
+}
+
+// Has reports whether optional argument was provided
+func (c *Context) Has(name string) bool {
+	_, ok := c.args[name]
+	return ok
+}
+
+// Arg returns parsed argument value or nil
+func (c *Context) Arg(name string) interface{} {
+	return c.args[name]
+}
+
+// String returns ArgString or ArgRest argument value
+func (c *Context) String(name string) string {
+	s, _ := c.args[name].(string)
+	return s
+}
+
+func (c *Context) Int(name string) int {
+	i, _ := c.args[name].(int)
+	return i
+}
+
+// UserArg returns ArgUser argument value
+func (c *Context) UserArg(name string) *banchogo.User {
+	u, _ := c.args[name].(*banchogo.User)
+	return u
+}
+
+// Beatmap returns ArgBeatmap argument value
+func (c *Context) Beatmap(name string) Beatmap {
+	b, _ := c.args[name].(Beatmap)
+	return b
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Cooldown limits how often a command can be invoked.
+// Zero durations are ignored.
+type Cooldown struct {
+	// PerUser limits invocations of a command by the same user
+	PerUser time.Duration
+	// PerChannel limits invocations of a command in the same channel or private conversation
+	PerChannel time.Duration
+	// Global limits invocations of a command by everyone
+	Global time.Duration
+}
+
+type cooldownEntry struct {
+	expires  time.Time
+	notified bool
+}
+
+// cooldowns stores when command can be invoked again for each cooldown key
+type cooldowns struct {
+	mu      sync.Mutex
+	entries map[string]*cooldownEntry
+	checks  int
+
+	now func() time.Time
+}
+
+func newCooldowns() *cooldowns {
+	return &cooldowns{
+		entries: make(map[string]*cooldownEntry),
+		now:     time.Now,
+	}
+}
+
+type cooldownKey struct {
+	key      string
+	duration time.Duration
+}
+
+// take checks all keys and if none of them are on cooldown, puts all of them on cooldown.
+// Otherwise, returns remaining time and whether user should be notified about it.
+// User is notified only once per cooldown, so spamming a command doesn't produce a reply for each message.
+func (c *cooldowns) take(keys ...cooldownKey) (remaining time.Duration, notify bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.checks++
+	if c.checks%256 == 0 {
+		c.purge(now)
+	}
+
+	var blocking *cooldownEntry
+	for _, k := range keys {
+		if k.duration <= 0 {
+			continue
+		}
+		if e, ok := c.entries[k.key]; ok && e.expires.After(now) {
+			if left := e.expires.Sub(now); left > remaining {
+				remaining = left
+				blocking = e
+			}
+		}
+	}
+
+	if blocking != nil {
+		notify = !blocking.notified
+		blocking.notified = true
+		return
+	}
+
+	for _, k := range keys {
+		if k.duration <= 0 {
+			continue
+		}
+		c.entries[k.key] = &cooldownEntry{expires: now.Add(k.duration)}
+	}
+	return 0, false
+}
+
+func (c *cooldowns) purge(now time.Time) {
+	for k, e := range c.entries {
+		if !e.expires.After(now) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// checkCooldown returns remaining cooldown for the invocation, zero if command can be invoked
+func (r *Router) checkCooldown(ctx *Context) (time.Duration, bool) {
+	cd := ctx.Command.Cooldown
+	user := normalizeUsername(ctx.User.Name())
+
+	return r.cooldowns.take(
+		cooldownKey{"user:" + user, r.UserCooldown},
+		cooldownKey{"cmd-user:" + ctx.Command.Name + ":" + user, cd.PerUser},
+		cooldownKey{"cmd-channel:" + ctx.Command.Name + ":" + ctx.Sender.Name(), cd.PerChannel},
+		cooldownKey{"cmd:" + ctx.Command.Name, cd.Global},
+	)
+}
+
+func formatSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
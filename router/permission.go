@@ -0,0 +1,61 @@
+package router
+
+import "strings"
+
+// Permission is a level of access required to invoke a command.
+// Every level includes all levels below it.
+type Permission int
+
+const (
+	Everyone Permission = iota
+	// Referee is a referee of the multiplayer lobby where command was invoked
+	Referee
+	// Moderator is a channel member with +o mode
+	Moderator
+	// Operator is one of the usernames from Options.Operators
+	Operator
+	// Owner is the username from Options.Owner
+	Owner
+)
+
+func (p Permission) String() string {
+	switch p {
+	case Referee:
+		return "referee"
+	case Moderator:
+		return "moderator"
+	case Operator:
+		return "operator"
+	case Owner:
+		return "owner"
+	default:
+		return "everyone"
+	}
+}
+
+// PermissionOf returns the highest permission level of the user who invoked a command
+func (r *Router) PermissionOf(ctx *Context) Permission {
+	name := normalizeUsername(ctx.User.Name())
+
+	if r.owner != "" && name == r.owner {
+		return Owner
+	}
+	if _, ok := r.operators[name]; ok {
+		return Operator
+	}
+
+	if channel := ctx.Channel(); channel != nil {
+		if member, ok := channel.Members.Load(ctx.User.Name()); ok && member.IsModerator() {
+			return Moderator
+		}
+		if ctx.Scope == ScopeMultiplayer && r.IsReferee != nil && r.IsReferee(channel, ctx.User) {
+			return Referee
+		}
+	}
+
+	return Everyone
+}
+
+func normalizeUsername(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+}
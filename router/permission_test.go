@@ -0,0 +1,129 @@
+package router
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+func TestRouter_Permissions(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "bot"})
+	r := New(Options{
+		Owner:     "Owner Name",
+		Operators: []string{"op"},
+		IsReferee: func(_ *banchogo.Channel, user *banchogo.User) bool {
+			return user.Name() == "ref"
+		},
+	})
+
+	called := map[string]bool{}
+	for _, perm := range []Permission{Referee, Moderator, Operator, Owner} {
+		name := perm.String()
+		r.MustRegister(&Command{
+			Name:       name,
+			Permission: perm,
+			Handler: func(ctx *Context) error {
+				called[ctx.User.Name()+":"+name] = true
+				return nil
+			},
+		})
+	}
+
+	channel, _ := client.GetChannel("#mp_1")
+	channel.Members.Store("mod", &banchogo.ChannelMember{Channel: channel, User: client.GetUser("mod"), Mode: banchogo.IRCModerator})
+
+	for _, user := range []string{"nobody", "ref", "mod", "op", "owner_name"} {
+		for _, cmd := range []string{"referee", "moderator", "operator", "owner"} {
+			r.Dispatch(client, client.GetUser(user), channel, "!"+cmd)
+		}
+	}
+
+	expected := map[string]bool{
+		"ref:referee":          true,
+		"mod:referee":          true,
+		"mod:moderator":        true,
+		"op:referee":           true,
+		"op:moderator":         true,
+		"op:operator":          true,
+		"owner_name:referee":   true,
+		"owner_name:moderator": true,
+		"owner_name:operator":  true,
+		"owner_name:owner":     true,
+	}
+	for k := range called {
+		if !expected[k] {
+			t.Errorf("%s shouldn't be allowed", k)
+		}
+	}
+	for k := range expected {
+		if !called[k] {
+			t.Errorf("%s should be allowed", k)
+		}
+	}
+}
+
+func TestRouter_Cooldown(t *testing.T) {
+	r, client := newTestRouter()
+	now := time.Unix(0, 0)
+	r.cooldowns.now = func() time.Time { return now }
+
+	calls := 0
+	r.MustRegister(&Command{
+		Name:     "roll",
+		Cooldown: Cooldown{PerUser: 10 * time.Second},
+		Handler: func(ctx *Context) error {
+			calls++
+			return nil
+		},
+	})
+
+	alice := &testSender{name: "alice", kind: "user"}
+	bob := &testSender{name: "bob", kind: "user"}
+
+	for i := 0; i < 5; i++ {
+		r.Dispatch(client, client.GetUser("alice"), alice, "!roll")
+	}
+	r.Dispatch(client, client.GetUser("bob"), bob, "!roll")
+
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+	if len(alice.messages) != 1 || !strings.Contains(alice.messages[0], "try again in 10s") {
+		t.Errorf("expected a single cooldown reply, got %v", alice.messages)
+	}
+
+	now = now.Add(10 * time.Second)
+	r.Dispatch(client, client.GetUser("alice"), alice, "!roll")
+	if calls != 3 {
+		t.Errorf("cooldown didn't expire")
+	}
+
+	// A usage error doesn't use up the cooldown
+	r.MustRegister(&Command{
+		Name:     "add",
+		Args:     []Arg{{Name: "n", Type: ArgInt}},
+		Cooldown: Cooldown{PerUser: 10 * time.Second},
+		Handler:  func(ctx *Context) error { calls++; return nil },
+	})
+	r.Dispatch(client, client.GetUser("bob"), bob, "!add x")
+	r.Dispatch(client, client.GetUser("bob"), bob, "!add 1")
+	if calls != 4 {
+		t.Errorf("command should run after a usage error, got %d calls", calls)
+	}
+
+	// Repeated malformed calls get a single usage reply
+	carol := &testSender{name: "carol", kind: "user"}
+	for i := 0; i < 5; i++ {
+		r.Dispatch(client, client.GetUser("carol"), carol, "!add x")
+	}
+	if len(carol.messages) != 1 {
+		t.Errorf("expected a single usage reply, got %v", carol.messages)
+	}
+	now = now.Add(deniedReplyInterval)
+	r.Dispatch(client, client.GetUser("carol"), carol, "!add x")
+	if len(carol.messages) != 2 {
+		t.Errorf("usage replies should resume after %s, got %v", deniedReplyInterval, carol.messages)
+	}
+}
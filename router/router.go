@@ -0,0 +1,385 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+const (
+	// Bancho cuts long messages, so help output is split into several messages
+	maxMessageLength = 400
+
+	// deniedReplyInterval limits "no permission" and usage error replies, so they can't be used to spam
+	deniedReplyInterval = 30 * time.Second
+)
+
+var ErrCommandExists = errors.New("command with this name or alias already exists")
+
+type Options struct {
+	// Prefixes by default is "!"
+	Prefixes []string
+
+	// HelpCommand is a name of auto generated help command, "help" by default
+	HelpCommand string
+	DisableHelp bool
+
+	// OnError is called when handler returns an error or arguments can't be parsed.
+	// By default, replies with command usage on argument error and with error text otherwise
+	OnError func(ctx *Context, err error)
+
+	// Owner and Operators are usernames with Owner and Operator permissions.
+	// Both of them ignore cooldowns.
+	Owner     string
+	Operators []string
+
+	// IsReferee reports whether user is a referee of a multiplayer channel.
+	// If nil, nobody gets Referee permission besides Operators and Owner.
+	IsReferee func(channel *banchogo.Channel, user *banchogo.User) bool
+
+	// UserCooldown limits how often a single user can invoke any command.
+	// All outgoing messages share one rate limiter, so without it one user can starve everyone else of replies.
+	UserCooldown time.Duration
+}
+
+// Router parses commands from incoming messages and calls registered handlers
+type Router struct {
+	mu       sync.RWMutex
+	commands map[string]*Command
+	ordered  []*Command
+
+	owner     string
+	operators map[string]struct{}
+	cooldowns *cooldowns
+
+	Prefixes     []string
+	OnError      func(ctx *Context, err error)
+	IsReferee    func(channel *banchogo.Channel, user *banchogo.User) bool
+	UserCooldown time.Duration
+}
+
+func New(opt Options) (r *Router) {
+	r = &Router{
+		commands:  make(map[string]*Command),
+		owner:     normalizeUsername(opt.Owner),
+		operators: make(map[string]struct{}, len(opt.Operators)),
+		cooldowns: newCooldowns(),
+
+		Prefixes:     opt.Prefixes,
+		OnError:      opt.OnError,
+		IsReferee:    opt.IsReferee,
+		UserCooldown: opt.UserCooldown,
+	}
+
+	for _, op := range opt.Operators {
+		r.operators[normalizeUsername(op)] = struct{}{}
+	}
+
+	if len(r.Prefixes) == 0 {
+		r.Prefixes = []string{"!"}
+	}
+	if r.OnError == nil {
+		r.OnError = defaultErrorHandler
+	}
+
+	if !opt.DisableHelp {
+		name := opt.HelpCommand
+		if name == "" {
+			name = "help"
+		}
+		r.MustRegister(&Command{
+			Name:        name,
+			Description: "shows available commands",
+			Args:        []Arg{{Name: "command", Optional: true}},
+			Handler:     r.helpHandler,
+		})
+	}
+
+	return
+}
+
+// Register adds a command to the router
+func (r *Router) Register(cmd *Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range cmd.names() {
+		if _, ok := r.commands[strings.ToLower(name)]; ok {
+			return fmt.Errorf("%w: %s", ErrCommandExists, name)
+		}
+	}
+	for _, name := range cmd.names() {
+		r.commands[strings.ToLower(name)] = cmd
+	}
+	r.ordered = append(r.ordered, cmd)
+	return nil
+}
+
+// MustRegister same as Register, but panics on error
+func (r *Router) MustRegister(cmd *Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a command with all of its aliases
+func (r *Router) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd, ok := r.commands[strings.ToLower(name)]
+	if !ok {
+		return
+	}
+	for _, n := range cmd.names() {
+		delete(r.commands, strings.ToLower(n))
+	}
+	for i, c := range r.ordered {
+		if c == cmd {
+			r.ordered = append(r.ordered[:i], r.ordered[i+1:]...)
+			break
+		}
+	}
+}
+
+// Command finds a command by its name or alias
+func (r *Router) Command(name string) *Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.commands[strings.ToLower(name)]
+}
+
+// Commands returns all registered commands in registration order
+func (r *Router) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*Command(nil), r.ordered...)
+}
+
+// Listen subscribes router to private and channel messages of the client.
+// Every command is handled in a separate goroutine, so handlers can safely wait for responses like User.Stats().
+// Returns a function that unsubscribes router.
+func (r *Router) Listen(client *banchogo.Client) func() {
+	removePrivate := client.OnPrivateMessage(func(m *banchogo.PrivateMessage) {
+		if m.Self {
+			return
+		}
+		go r.Dispatch(client, m.User, m.Sender(), m.Content())
+	})
+	removeChannel := client.OnChannelMessage(func(m *banchogo.ChannelMessage) {
+		if m.Self {
+			return
+		}
+		go r.Dispatch(client, m.User, m.Sender(), m.Content())
+	})
+
+	return func() {
+		removePrivate()
+		removeChannel()
+	}
+}
+
+// ListenChannel same as Listen, but only handles messages from a single channel
+func (r *Router) ListenChannel(client *banchogo.Client, channel *banchogo.Channel) func() {
+	return channel.OnMessage(func(m *banchogo.ChannelMessage) {
+		if m.Self {
+			return
+		}
+		go r.Dispatch(client, m.User, m.Sender(), m.Content())
+	})
+}
+
+// Dispatch parses content and calls matching command handler in the calling goroutine.
+// Returns false if content is not a command of this router.
+func (r *Router) Dispatch(client *banchogo.Client, user *banchogo.User, sender banchogo.MessageSender, content string) bool {
+	prefix := r.matchPrefix(content)
+	if prefix == "" {
+		return false
+	}
+
+	body := content[len(prefix):]
+	name, rest, _ := strings.Cut(body, " ")
+	cmd := r.Command(name)
+	if cmd == nil {
+		return false
+	}
+
+	ctx := &Context{
+		Router:  r,
+		Client:  client,
+		User:    user,
+		Sender:  sender,
+		Command: cmd,
+		Prefix:  prefix,
+		Invoked: name,
+		Content: content,
+		Scope:   scopeOf(sender),
+	}
+
+	if !cmd.Scope.Allows(ctx.Scope) {
+		return false
+	}
+
+	perm := r.PermissionOf(ctx)
+	if perm < cmd.Permission {
+		if left, _ := r.cooldowns.take(cooldownKey{"denied:" + normalizeUsername(user.Name()), deniedReplyInterval}); left == 0 {
+			ctx.Replyf("You need %s permission to use %s%s", cmd.Permission, prefix, cmd.Name)
+		}
+		return true
+	}
+
+	args, err := parseArgs(ctx, cmd.Args, rest)
+	if err != nil {
+		// A typo doesn't use up the cooldown, but usage replies are limited separately
+		if perm >= Operator {
+			r.OnError(ctx, err)
+		} else if left, _ := r.cooldowns.take(cooldownKey{"usage:" + normalizeUsername(user.Name()), deniedReplyInterval}); left == 0 {
+			r.OnError(ctx, err)
+		}
+		return true
+	}
+	ctx.args = args
+
+	// Cooldown is checked after parsing, a typo in arguments shouldn't use it up
+	if perm < Operator {
+		if left, notify := r.checkCooldown(ctx); left > 0 {
+			if notify {
+				ctx.Replyf("%s, please try again in %ds", user.Name(), formatSeconds(left))
+			}
+			return true
+		}
+	}
+
+	if err = cmd.Handler(ctx); err != nil {
+		r.OnError(ctx, err)
+	}
+	return true
+}
+
+func (r *Router) matchPrefix(content string) string {
+	for _, p := range r.Prefixes {
+		if strings.HasPrefix(content, p) && len(content) > len(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func (r *Router) helpHandler(ctx *Context) error {
+	if ctx.Has("command") {
+		cmd := r.Command(strings.TrimPrefix(ctx.String("command"), ctx.Prefix))
+		if cmd == nil || cmd.Hidden || !cmd.Scope.Allows(ctx.Scope) {
+			return ctx.Replyf("Unknown command %q", ctx.String("command"))
+		}
+
+		help := cmd.Usage(ctx.Prefix)
+		if cmd.Description != "" {
+			help += " - " + cmd.Description
+		}
+		if len(cmd.Aliases) > 0 {
+			help += " (aliases: " + ctx.Prefix + strings.Join(cmd.Aliases, ", "+ctx.Prefix) + ")"
+		}
+		return ctx.Reply(help)
+	}
+
+	perm := r.PermissionOf(ctx)
+	var names []string
+	for _, cmd := range r.Commands() {
+		if cmd.Hidden || !cmd.Scope.Allows(ctx.Scope) || perm < cmd.Permission {
+			continue
+		}
+		names = append(names, ctx.Prefix+cmd.Name)
+	}
+	sort.Strings(names)
+
+	for _, line := range joinLines("Commands: ", names, ", ", maxMessageLength) {
+		if err := ctx.Reply(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseArgs(ctx *Context, spec []Arg, content string) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(spec))
+
+	tokens, err := tokenize(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, a := range spec {
+		if i >= len(tokens) {
+			if a.Optional {
+				continue
+			}
+			return nil, &ArgError{Arg: a, Err: ErrMissingArgument}
+		}
+
+		t := tokens[i]
+		switch a.Type {
+		case ArgInt:
+			v, err := strconv.Atoi(t.value)
+			if err != nil {
+				return nil, &ArgError{a, t.value, err}
+			}
+			args[a.Name] = v
+		case ArgUser:
+			args[a.Name] = ctx.Client.GetUser(t.value)
+		case ArgBeatmap:
+			b, err := parseBeatmap(t.value)
+			if err != nil {
+				return nil, &ArgError{a, t.value, err}
+			}
+			args[a.Name] = b
+		case ArgRest:
+			args[a.Name] = strings.TrimSpace(content[t.start:])
+			return args, nil
+		default:
+			args[a.Name] = t.value
+		}
+	}
+
+	return args, nil
+}
+
+func scopeOf(sender banchogo.MessageSender) Scope {
+	switch {
+	case sender.Type() == "user":
+		return ScopePrivate
+	case sender.Type() == "mp" || strings.HasPrefix(sender.Name(), "#mp_"):
+		return ScopeMultiplayer
+	default:
+		return ScopeChannel
+	}
+}
+
+func defaultErrorHandler(ctx *Context, err error) {
+	var argErr *ArgError
+	if errors.As(err, &argErr) || errors.Is(err, ErrUnterminatedQuote) {
+		ctx.Replyf("%s. Usage: %s", err, ctx.Command.Usage(ctx.Prefix))
+		return
+	}
+	ctx.Replyf("Error: %s", err)
+}
+
+// joinLines joins items into lines no longer than max characters
+func joinLines(header string, items []string, sep string, max int) (lines []string) {
+	line := header
+	for i, item := range items {
+		if i > 0 && len(line)+len(sep)+len(item) > max {
+			lines = append(lines, line)
+			line = ""
+		} else if i > 0 {
+			line += sep
+		}
+		line += item
+	}
+	return append(lines, line)
+}
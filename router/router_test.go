@@ -0,0 +1,155 @@
+package router
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+)
+
+type testSender struct {
+	name     string
+	kind     string
+	messages []string
+}
+
+func (s *testSender) Name() string { return s.name }
+
+func (s *testSender) SendMessage(m string) error {
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func (s *testSender) SendAction(m string) error { return s.SendMessage(m) }
+
+func (s *testSender) Type() string { return s.kind }
+
+func newTestRouter() (*Router, *banchogo.Client) {
+	return New(Options{}), banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "bot"})
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`one  "two three" "say \"hi\"" four`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"one", "two three", `say "hi"`, "four"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, tok := range tokens {
+		if tok.value != expected[i] {
+			t.Errorf("token %d: expected %q, got %q", i, expected[i], tok.value)
+		}
+	}
+
+	if _, err = tokenize(`"unterminated`); !errors.Is(err, ErrUnterminatedQuote) {
+		t.Error("expected unterminated quote error")
+	}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	r, client := newTestRouter()
+	var got *Context
+	r.MustRegister(&Command{
+		Name:    "map",
+		Aliases: []string{"m"},
+		Args: []Arg{
+			{Name: "beatmap", Type: ArgBeatmap},
+			{Name: "times", Type: ArgInt},
+			{Name: "player", Type: ArgUser},
+			{Name: "comment", Type: ArgRest, Optional: true},
+		},
+		Handler: func(ctx *Context) error {
+			got = ctx
+			return nil
+		},
+	})
+
+	sender := &testSender{name: "someone", kind: "user"}
+	ok := r.Dispatch(client, client.GetUser("someone"), sender,
+		`!M https://osu.ppy.sh/beatmapsets/1#mania/75 3 "Some Player" rest of "the" line`)
+	if !ok || got == nil {
+		t.Fatal("command wasn't dispatched")
+	}
+
+	if b := got.Beatmap("beatmap"); b.ID != 75 || b.SetID != 1 || b.Mode != "mania" {
+		t.Errorf("unexpected beatmap %+v", b)
+	}
+	if got.Int("times") != 3 {
+		t.Errorf("expected times 3, got %d", got.Int("times"))
+	}
+	if u := got.UserArg("player"); u == nil || u.Name() != "Some_Player" {
+		t.Errorf("unexpected user %v", u)
+	}
+	if got.String("comment") != `rest of "the" line` {
+		t.Errorf("unexpected rest %q", got.String("comment"))
+	}
+}
+
+func TestRouter_ArgumentError(t *testing.T) {
+	r, client := newTestRouter()
+	r.MustRegister(&Command{
+		Name:    "add",
+		Args:    []Arg{{Name: "a", Type: ArgInt}, {Name: "b", Type: ArgInt}},
+		Handler: func(ctx *Context) error { return ctx.Replyf("%d", ctx.Int("a")+ctx.Int("b")) },
+	})
+	sender := &testSender{name: "someone", kind: "user"}
+	now := time.Unix(0, 0)
+	r.cooldowns.now = func() time.Time { return now }
+
+	r.Dispatch(client, client.GetUser("someone"), sender, "!add 1 x")
+	// Usage replies to one user are limited
+	now = now.Add(deniedReplyInterval)
+	r.Dispatch(client, client.GetUser("someone"), sender, "!add 1")
+	r.Dispatch(client, client.GetUser("someone"), sender, "!add 1 2")
+
+	if len(sender.messages) != 3 {
+		t.Fatalf("expected 3 replies, got %v", sender.messages)
+	}
+	if !strings.HasSuffix(sender.messages[0], "Usage: !add <a:int> <b:int>") {
+		t.Errorf("unexpected reply %q", sender.messages[0])
+	}
+	if !strings.Contains(sender.messages[1], "missing argument") {
+		t.Errorf("unexpected reply %q", sender.messages[1])
+	}
+	if sender.messages[2] != "3" {
+		t.Errorf("unexpected reply %q", sender.messages[2])
+	}
+}
+
+func TestRouter_Scope(t *testing.T) {
+	r, client := newTestRouter()
+	called := 0
+	r.MustRegister(&Command{
+		Name:  "ref",
+		Scope: MultiplayerOnly,
+		Handler: func(ctx *Context) error {
+			called++
+			return nil
+		},
+	})
+
+	user := client.GetUser("someone")
+	r.Dispatch(client, user, &testSender{name: "someone", kind: "user"}, "!ref")
+	r.Dispatch(client, user, &testSender{name: "#osu", kind: "channel"}, "!ref")
+	r.Dispatch(client, user, &testSender{name: "#mp_123", kind: "channel"}, "!ref")
+
+	if called != 1 {
+		t.Errorf("expected command to be called once, got %d", called)
+	}
+
+	pm := &testSender{name: "someone", kind: "user"}
+	r.Dispatch(client, user, pm, "!help")
+	if len(pm.messages) != 1 || pm.messages[0] != "Commands: !help" {
+		t.Errorf("unexpected help output %v", pm.messages)
+	}
+
+	mp := &testSender{name: "#mp_123", kind: "channel"}
+	r.Dispatch(client, user, mp, "!help")
+	if len(mp.messages) != 1 || mp.messages[0] != "Commands: !help, !ref" {
+		t.Errorf("unexpected help output %v", mp.messages)
+	}
+}
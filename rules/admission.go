@@ -0,0 +1,336 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/outbox"
+	"github.com/thehowl/go-osuapi"
+)
+
+// Action is what AdmissionGuard does with a player who breaks admission rules
+type Action int
+
+const (
+	// Warn only sends a message to the lobby, e.g. to let referee decide
+	Warn Action = iota
+	// Kick removes the player with !mp kick
+	Kick
+	// Ban removes the player with !mp ban, so they can't rejoin
+	Ban
+)
+
+func (a Action) String() string {
+	switch a {
+	case Warn:
+		return "warn"
+	case Kick:
+		return "kick"
+	case Ban:
+		return "ban"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Player is data of a joining player admission rules are checked against
+type Player struct {
+	UserID   int
+	Username string
+	// Rank is global rank, 0 if the player is unranked
+	Rank int
+	// Country is ISO 3166-1 alpha-2 country code, empty if unknown
+	Country string
+}
+
+// AdmissionRules are requirements for players joining a lobby, zero values mean no requirement
+type AdmissionRules struct {
+	// MinRank and MaxRank are the allowed global rank range, e.g. 1000 and 10000 for a 4 digit tournament.
+	// Unranked players don't pass if any of them is set.
+	MinRank int
+	MaxRank int
+	// Mode is a game mode ranks are checked in
+	Mode osuapi.Mode
+
+	// AllowCountries and DenyCountries are country codes, compared case-insensitively.
+	// Players with unknown country don't pass the allow list.
+	AllowCountries []string
+	DenyCountries  []string
+
+	// Roster is a list of user ids allowed to join, e.g. registered tournament players
+	Roster []int
+
+	BannedUsers []int
+	// BannedNames are checked without an API lookup, spaces and underscores are interchangeable
+	BannedNames []string
+}
+
+// Check returns reasons why the player isn't allowed, nil if they pass all rules
+func (r *AdmissionRules) Check(p Player) []string {
+	var reasons []string
+	addf := func(format string, a ...any) {
+		reasons = append(reasons, fmt.Sprintf(format, a...))
+	}
+
+	if r.isBannedName(p.Username) || (p.UserID != 0 && containsInt(r.BannedUsers, p.UserID)) {
+		addf("banned from this lobby")
+	}
+	if len(r.Roster) > 0 && !containsInt(r.Roster, p.UserID) {
+		addf("not on the roster")
+	}
+
+	if (r.MinRank > 0 || r.MaxRank > 0) && p.Rank == 0 {
+		addf("unranked players are not allowed")
+	} else {
+		if r.MinRank > 0 && p.Rank < r.MinRank {
+			addf("#%d is better than the #%d rank limit", p.Rank, r.MinRank)
+		}
+		if r.MaxRank > 0 && p.Rank > r.MaxRank {
+			addf("#%d is worse than the #%d rank limit", p.Rank, r.MaxRank)
+		}
+	}
+
+	switch {
+	case p.Country == "" && len(r.AllowCountries) > 0:
+		addf("country is unknown")
+	case p.Country != "" && len(r.AllowCountries) > 0 && !containsFold(r.AllowCountries, p.Country),
+		p.Country != "" && containsFold(r.DenyCountries, p.Country):
+		addf("players from %s are not allowed", strings.ToUpper(p.Country))
+	}
+
+	return reasons
+}
+
+// needsLookup reports whether any rule requires player data besides the username
+func (r *AdmissionRules) needsLookup() bool {
+	return r.MinRank > 0 || r.MaxRank > 0 || len(r.AllowCountries) > 0 || len(r.DenyCountries) > 0 ||
+		len(r.Roster) > 0 || len(r.BannedUsers) > 0
+}
+
+func (r *AdmissionRules) isBannedName(name string) bool {
+	name = strings.ReplaceAll(name, " ", "_")
+	for _, n := range r.BannedNames {
+		if strings.EqualFold(strings.ReplaceAll(n, " ", "_"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+type AdmissionGuardOptions struct {
+	Rules  AdmissionRules
+	Action Action
+	// Lookup fetches player data. By default it's User.FetchFromAPI when the client has access to the osu! API,
+	// otherwise User.Stats which doesn't know the player's country.
+	Lookup func(u *banchogo.User) (Player, error)
+	// CacheTTL is how long looked up players are remembered, so rejoining players are checked instantly.
+	// 10 minutes by default, negative value disables caching.
+	CacheTTL time.Duration
+	// RejectUnknown rejects players whose data couldn't be looked up, by default they are let in
+	RejectUnknown bool
+	// OnReject is called for every rejected player, from a separate goroutine
+	OnReject func(u *banchogo.User, reasons []string)
+}
+
+// AdmissionGuard checks players joining a lobby and warns about, kicks or bans the ones who break the rules.
+// Lookups run in separate goroutines, so the client keeps reading messages while a player is checked.
+// Referees are never checked.
+type AdmissionGuard struct {
+	mu sync.Mutex
+
+	Lobby  *banchogo.Lobby
+	Rules  AdmissionRules
+	Action Action
+
+	lookup        func(u *banchogo.User) (Player, error)
+	cacheTTL      time.Duration
+	rejectUnknown bool
+	onReject      func(u *banchogo.User, reasons []string)
+
+	cache map[string]cachedPlayer
+
+	outbox          *outbox.Outbox
+	handlerRemovers []func()
+}
+
+type cachedPlayer struct {
+	player  Player
+	expires time.Time
+}
+
+func NewAdmissionGuard(lobby *banchogo.Lobby, opt AdmissionGuardOptions) *AdmissionGuard {
+	g := &AdmissionGuard{
+		Lobby:         lobby,
+		Rules:         opt.Rules,
+		Action:        opt.Action,
+		lookup:        opt.Lookup,
+		cacheTTL:      opt.CacheTTL,
+		rejectUnknown: opt.RejectUnknown,
+		onReject:      opt.OnReject,
+		cache:         make(map[string]cachedPlayer),
+	}
+	if g.lookup == nil {
+		g.lookup = g.defaultLookup
+	}
+	if g.cacheTTL == 0 {
+		g.cacheTTL = 10 * time.Minute
+	}
+	return g
+}
+
+// Start subscribes to lobby events and checks players who are already in the lobby
+func (g *AdmissionGuard) Start() {
+	g.mu.Lock()
+	g.outbox = outbox.New()
+	g.mu.Unlock()
+
+	g.handlerRemovers = []func(){
+		g.Lobby.OnPlayerJoined(g.onPlayerJoined),
+	}
+	for _, p := range g.Lobby.Players() {
+		g.onPlayerJoined(p)
+	}
+}
+
+func (g *AdmissionGuard) Stop() {
+	for _, f := range g.handlerRemovers {
+		f()
+	}
+	g.handlerRemovers = nil
+
+	g.mu.Lock()
+	g.outbox.Close()
+	g.outbox = nil
+	g.mu.Unlock()
+}
+
+func (g *AdmissionGuard) onPlayerJoined(p *banchogo.LobbyPlayer) {
+	u := p.User
+	if u.IsClient() || g.Lobby.IsReferee(u) {
+		return
+	}
+
+	if g.Rules.isBannedName(u.Name()) {
+		g.mu.Lock()
+		g.reject(u, []string{"banned from this lobby"})
+		g.mu.Unlock()
+		return
+	}
+	if g.Rules.needsLookup() {
+		go g.check(u)
+	}
+}
+
+func (g *AdmissionGuard) check(u *banchogo.User) {
+	player, err := g.player(u)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// The player may leave while being looked up
+	if g.outbox == nil || g.Lobby.Player(u) == nil {
+		return
+	}
+
+	var reasons []string
+	if err != nil {
+		if !g.rejectUnknown {
+			return
+		}
+		reasons = []string{"couldn't look up player data"}
+	} else {
+		reasons = g.Rules.Check(player)
+	}
+	if len(reasons) > 0 {
+		g.reject(u, reasons)
+	}
+}
+
+// player returns cached player data or looks it up
+func (g *AdmissionGuard) player(u *banchogo.User) (Player, error) {
+	key := strings.ToLower(u.Name())
+
+	g.mu.Lock()
+	c, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		return c.player, nil
+	}
+
+	p, err := g.lookup(u)
+	if err != nil {
+		return Player{}, err
+	}
+	if p.Username == "" {
+		p.Username = u.Name()
+	}
+
+	if g.cacheTTL > 0 {
+		g.mu.Lock()
+		g.cache[key] = cachedPlayer{p, time.Now().Add(g.cacheTTL)}
+		g.mu.Unlock()
+	}
+	return p, nil
+}
+
+func (g *AdmissionGuard) defaultLookup(u *banchogo.User) (Player, error) {
+	c := g.Lobby.Client
+	if c.ApiV2 != nil || c.Api != (osuapi.Client{}) {
+		d, err := u.FetchFromAPI(int(g.Rules.Mode))
+		if err != nil {
+			return Player{}, err
+		}
+		return Player{UserID: d.UserID, Username: u.Name(), Rank: d.Rank, Country: d.Country}, nil
+	}
+
+	s := <-u.Stats()
+	if s.Error != nil {
+		return Player{}, s.Error
+	}
+	return Player{UserID: s.UserID, Username: u.Name(), Rank: s.Rank}, nil
+}
+
+// reject must be called with g.mu locked
+func (g *AdmissionGuard) reject(u *banchogo.User, reasons []string) {
+	name, why := u.Name(), strings.Join(reasons, ", ")
+	switch g.Action {
+	case Kick:
+		g.say("%s can't play in this lobby: %s", name, why)
+		g.outbox.Send(func() error { return g.Lobby.Kick(u) })
+	case Ban:
+		g.say("%s is banned from this lobby: %s", name, why)
+		g.outbox.Send(func() error { return g.Lobby.Ban(u) })
+	default:
+		g.say("Warning: %s doesn't meet the lobby requirements: %s", name, why)
+	}
+
+	if g.onReject != nil {
+		go g.onReject(u, reasons)
+	}
+}
+
+// say queues a message to the lobby, must be called with g.mu locked
+func (g *AdmissionGuard) say(format string, a ...any) {
+	text := fmt.Sprintf(format, a...)
+	g.outbox.Send(func() error { return g.Lobby.SendMessage(text) })
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/banchotest"
+)
+
+func TestAdmissionRules_Check(t *testing.T) {
+	r := AdmissionRules{
+		MinRank:        1000,
+		MaxRank:        9999,
+		AllowCountries: []string{"us", "GB"},
+		Roster:         []int{1, 2},
+		BannedNames:    []string{"Bad Player"},
+	}
+
+	if reasons := r.Check(Player{UserID: 1, Username: "Good", Rank: 5000, Country: "US"}); reasons != nil {
+		t.Errorf("player should pass, got %v", reasons)
+	}
+
+	want := []string{"banned from this lobby", "not on the roster", "#523 is better than the #1000 rank limit", "players from DE are not allowed"}
+	if reasons := r.Check(Player{UserID: 3, Username: "bad_player", Rank: 523, Country: "de"}); !reflect.DeepEqual(reasons, want) {
+		t.Errorf("unexpected reasons %q", reasons)
+	}
+
+	want = []string{"unranked players are not allowed", "country is unknown"}
+	if reasons := r.Check(Player{UserID: 2, Username: "Inactive"}); !reflect.DeepEqual(reasons, want) {
+		t.Errorf("unexpected reasons %q", reasons)
+	}
+}
+
+func TestAdmissionGuard(t *testing.T) {
+	client, conn := banchotest.NewClient(t, banchogo.ClientOptions{},
+		"200\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Cheater joined in slot 1.",
+		"200\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Good_Player joined in slot 2.",
+		"200\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Top_Player joined in slot 3.",
+		"200\t:BanchoBot!cho@ppy.sh PRIVMSG #mp_1 :Unknown joined in slot 4.",
+	)
+
+	players := map[string]Player{
+		"Good_Player": {UserID: 1, Rank: 5000},
+		"Top_Player":  {UserID: 2, Rank: 10},
+	}
+	lookups := make(chan string, 8)
+	g := NewAdmissionGuard(client.GetLobby(1), AdmissionGuardOptions{
+		Rules:  AdmissionRules{MinRank: 1000, BannedNames: []string{"cheater"}},
+		Action: Kick,
+		Lookup: func(u *banchogo.User) (Player, error) {
+			lookups <- u.Name()
+			if p, ok := players[u.Name()]; ok {
+				return p, nil
+			}
+			return Player{}, errors.New("user not found")
+		},
+	})
+	g.Start()
+	defer g.Stop()
+
+	sent := banchotest.WaitSent(t, conn, 4)
+	want := []string{
+		"PRIVMSG #mp_1 :Cheater can't play in this lobby: banned from this lobby",
+		"PRIVMSG #mp_1 :!mp kick Cheater",
+		"PRIVMSG #mp_1 :Top_Player can't play in this lobby: #10 is better than the #1000 rank limit",
+		"PRIVMSG #mp_1 :!mp kick Top_Player",
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+
+	// Banned names are rejected without a lookup
+	if len(lookups) != 3 {
+		t.Errorf("expected 3 lookups, got %d", len(lookups))
+	}
+	if p, err := g.player(client.GetUser("Top_Player")); err != nil || p.Rank != 10 || len(lookups) != 3 {
+		t.Error("looked up player should be cached")
+	}
+}
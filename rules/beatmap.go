@@ -0,0 +1,168 @@
+// Package rules enforces lobby rules: beatmap restrictions for picked maps
+// and admission policies for joining players.
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/thehowl/go-osuapi"
+)
+
+var modeNames = [...]string{"osu!", "taiko", "catch", "mania"}
+
+// BeatmapRules are limits for beatmaps picked in a lobby, zero values mean no limit.
+// Star rating, length and BPM are nomod values.
+type BeatmapRules struct {
+	MinStars float64
+	MaxStars float64
+
+	MinLength time.Duration
+	MaxLength time.Duration
+
+	MaxBPM float64
+
+	// Statuses are allowed ranked statuses, e.g. osuapi.StatusRanked and osuapi.StatusLoved
+	Statuses []osuapi.ApprovedStatus
+	// Modes are allowed game modes, converts are checked by the mode of the beatmap itself
+	Modes []osuapi.Mode
+
+	// BannedMappers are compared case-insensitively with the beatmap creator
+	BannedMappers []string
+	BannedMaps    []int
+	BannedSets    []int
+}
+
+// Check returns reasons why the beatmap isn't allowed, nil if it passes all rules
+func (r *BeatmapRules) Check(b *banchogo.Beatmap) []string {
+	var reasons []string
+	addf := func(format string, a ...any) {
+		reasons = append(reasons, fmt.Sprintf(format, a...))
+	}
+
+	if r.MinStars > 0 && b.StarRating < r.MinStars {
+		addf("%.2f* is below the %.2f* minimum", b.StarRating, r.MinStars)
+	}
+	if r.MaxStars > 0 && b.StarRating > r.MaxStars {
+		addf("%.2f* is above the %.2f* limit", b.StarRating, r.MaxStars)
+	}
+	if r.MinLength > 0 && b.Length < r.MinLength {
+		addf("length %s is below the %s minimum", formatLength(b.Length), formatLength(r.MinLength))
+	}
+	if r.MaxLength > 0 && b.Length > r.MaxLength {
+		addf("length %s is above the %s limit", formatLength(b.Length), formatLength(r.MaxLength))
+	}
+	if r.MaxBPM > 0 && b.BPM > r.MaxBPM {
+		addf("%.0f BPM is above the %.0f BPM limit", b.BPM, r.MaxBPM)
+	}
+	if len(r.Statuses) > 0 && !containsStatus(r.Statuses, b.Status) {
+		addf("%s maps are not allowed", b.Status)
+	}
+	if len(r.Modes) > 0 && !containsMode(r.Modes, b.Mode) {
+		addf("%s maps are not allowed", modeName(b.Mode))
+	}
+	for _, m := range r.BannedMappers {
+		if strings.EqualFold(m, b.Creator) {
+			addf("maps by %s are banned", b.Creator)
+			break
+		}
+	}
+	if r.IsBannedMap(b.ID, b.SetID) {
+		addf("this map is banned")
+	}
+
+	return reasons
+}
+
+// IsBannedMap reports whether the beatmap or its set is banned, setID may be zero if unknown
+func (r *BeatmapRules) IsBannedMap(id, setID int) bool {
+	for _, m := range r.BannedMaps {
+		if m == id {
+			return true
+		}
+	}
+	for _, s := range r.BannedSets {
+		if setID != 0 && s == setID {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the rules in a single line, e.g. to answer !rules command
+func (r *BeatmapRules) String() string {
+	var parts []string
+
+	switch {
+	case r.MinStars > 0 && r.MaxStars > 0:
+		parts = append(parts, fmt.Sprintf("%.2f-%.2f*", r.MinStars, r.MaxStars))
+	case r.MinStars > 0:
+		parts = append(parts, fmt.Sprintf("%.2f*+", r.MinStars))
+	case r.MaxStars > 0:
+		parts = append(parts, fmt.Sprintf("up to %.2f*", r.MaxStars))
+	}
+
+	switch {
+	case r.MinLength > 0 && r.MaxLength > 0:
+		parts = append(parts, fmt.Sprintf("%s-%s long", formatLength(r.MinLength), formatLength(r.MaxLength)))
+	case r.MinLength > 0:
+		parts = append(parts, fmt.Sprintf("at least %s long", formatLength(r.MinLength)))
+	case r.MaxLength > 0:
+		parts = append(parts, fmt.Sprintf("up to %s long", formatLength(r.MaxLength)))
+	}
+
+	if r.MaxBPM > 0 {
+		parts = append(parts, fmt.Sprintf("up to %.0f BPM", r.MaxBPM))
+	}
+	if len(r.Statuses) > 0 {
+		names := make([]string, len(r.Statuses))
+		for i, s := range r.Statuses {
+			names[i] = s.String()
+		}
+		parts = append(parts, strings.Join(names, "/")+" only")
+	}
+	if len(r.Modes) > 0 {
+		names := make([]string, len(r.Modes))
+		for i, m := range r.Modes {
+			names[i] = modeName(m)
+		}
+		parts = append(parts, strings.Join(names, "/")+" only")
+	}
+
+	if len(parts) == 0 {
+		return "any map"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatLength(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+func modeName(m osuapi.Mode) string {
+	if m >= 0 && int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return m.String()
+}
+
+func containsStatus(list []osuapi.ApprovedStatus, s osuapi.ApprovedStatus) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMode(list []osuapi.Mode, m osuapi.Mode) bool {
+	for _, v := range list {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
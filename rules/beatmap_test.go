@@ -0,0 +1,47 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestBeatmapRules_Check(t *testing.T) {
+	r := BeatmapRules{
+		MinStars:      3,
+		MaxStars:      5,
+		MaxLength:     5 * time.Minute,
+		MaxBPM:        200,
+		Statuses:      []osuapi.ApprovedStatus{osuapi.StatusRanked, osuapi.StatusLoved},
+		Modes:         []osuapi.Mode{osuapi.ModeOsu},
+		BannedMappers: []string{"BadMapper"},
+		BannedSets:    []int{10},
+	}
+
+	ok := &banchogo.Beatmap{ID: 1, SetID: 1, StarRating: 4, Length: 3 * time.Minute, BPM: 180, Status: osuapi.StatusRanked}
+	if reasons := r.Check(ok); reasons != nil {
+		t.Errorf("map should pass, got %v", reasons)
+	}
+
+	bad := &banchogo.Beatmap{ID: 2, SetID: 10, Creator: "badmapper", StarRating: 6.5, Length: 312 * time.Second,
+		BPM: 240, Status: osuapi.StatusGraveyard, Mode: osuapi.ModeTaiko}
+	want := []string{
+		"6.50* is above the 5.00* limit",
+		"length 5:12 is above the 5:00 limit",
+		"240 BPM is above the 200 BPM limit",
+		"graveyard maps are not allowed",
+		"taiko maps are not allowed",
+		"maps by badmapper are banned",
+		"this map is banned",
+	}
+	if reasons := r.Check(bad); !reflect.DeepEqual(reasons, want) {
+		t.Errorf("unexpected reasons %q", reasons)
+	}
+
+	if s := r.String(); s != "3.00-5.00*, up to 5:00 long, up to 200 BPM, ranked/loved only, osu! only" {
+		t.Errorf("unexpected description %q", s)
+	}
+}
@@ -0,0 +1,192 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/outbox"
+	"github.com/thehowl/go-osuapi"
+)
+
+type BeatmapGuardOptions struct {
+	Rules BeatmapRules
+	// MaxViolations is how many disallowed maps in a row the host can pick before being skipped, 3 by default.
+	// Negative value disables skipping.
+	MaxViolations int
+	// SkipHost passes host to another player, e.g. AutoHost.Rotate.
+	// By default host goes to the player in the next occupied slot.
+	SkipHost func() error
+}
+
+// BeatmapGuard checks every beatmap picked in a lobby and reverts to the previous map if it breaks the rules.
+// Beatmap metadata comes from Lobby.OnBeatmapResolved, so the client needs access to the osu! API;
+// without it only banned map ids are enforced.
+type BeatmapGuard struct {
+	mu sync.Mutex
+
+	Lobby *banchogo.Lobby
+	Rules BeatmapRules
+
+	maxViolations int
+	skipHost      func() error
+
+	current      int
+	accepted     int
+	acceptedMode osuapi.Mode
+	// reverting is a map id the guard is reverting to, its change isn't checked
+	reverting int
+	// rejected is a map id which broke the rules and is still selected
+	rejected   int
+	violations int
+
+	outbox          *outbox.Outbox
+	handlerRemovers []func()
+}
+
+func NewBeatmapGuard(lobby *banchogo.Lobby, opt BeatmapGuardOptions) *BeatmapGuard {
+	g := &BeatmapGuard{
+		Lobby:         lobby,
+		Rules:         opt.Rules,
+		maxViolations: opt.MaxViolations,
+		skipHost:      opt.SkipHost,
+	}
+	if g.maxViolations == 0 {
+		g.maxViolations = 3
+	}
+	if g.skipHost == nil {
+		g.skipHost = g.nextSlotHost
+	}
+	return g
+}
+
+// Start subscribes to lobby events, the current map is accepted as is
+func (g *BeatmapGuard) Start() {
+	g.mu.Lock()
+	g.outbox = outbox.New()
+	g.current = g.Lobby.BeatmapID()
+	g.accepted, g.acceptedMode = g.current, g.Lobby.Mode()
+	g.mu.Unlock()
+
+	l := g.Lobby
+	g.handlerRemovers = []func(){
+		l.OnBeatmapChanged(g.onBeatmapChanged),
+		l.OnBeatmapResolved(g.onBeatmapResolved),
+		l.OnHost(g.onHost),
+		l.OnMatchStarted(g.onMatchStarted),
+	}
+}
+
+func (g *BeatmapGuard) Stop() {
+	for _, f := range g.handlerRemovers {
+		f()
+	}
+	g.handlerRemovers = nil
+
+	g.mu.Lock()
+	g.outbox.Close()
+	g.outbox = nil
+	g.mu.Unlock()
+}
+
+func (g *BeatmapGuard) onBeatmapChanged(id int, title string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.current = id
+	if g.reverting != 0 && id == g.reverting {
+		g.reverting = 0
+		g.rejected = 0
+		return
+	}
+	g.rejected = 0
+
+	if g.Rules.IsBannedMap(id, 0) {
+		g.reject(id, title, []string{"this map is banned"})
+	}
+}
+
+func (g *BeatmapGuard) onBeatmapResolved(b *banchogo.Beatmap) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if b.ID != g.current || b.ID == g.rejected || (b.ID == g.accepted && g.reverting == 0) {
+		return
+	}
+
+	if reasons := g.Rules.Check(b); len(reasons) > 0 {
+		g.reject(b.ID, b.FullTitle(), reasons)
+		return
+	}
+	// The lobby mode is kept, a converted map has a different mode than the lobby
+	g.accepted, g.acceptedMode = b.ID, g.Lobby.Mode()
+	g.violations = 0
+}
+
+func (g *BeatmapGuard) onHost(*banchogo.LobbyPlayer) {
+	g.mu.Lock()
+	g.violations = 0
+	g.mu.Unlock()
+}
+
+// onMatchStarted aborts the match if the host started it before the map was reverted
+func (g *BeatmapGuard) onMatchStarted() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.rejected != 0 && g.rejected == g.current {
+		g.outbox.Send(g.Lobby.Abort)
+		g.say("The match was aborted, the map isn't allowed")
+	}
+}
+
+// reject must be called with g.mu locked
+func (g *BeatmapGuard) reject(id int, title string, reasons []string) {
+	g.rejected = id
+	g.violations++
+
+	if g.accepted != 0 && g.accepted != id {
+		g.reverting = g.accepted
+		accepted, mode := g.accepted, g.acceptedMode
+		g.say("%s isn't allowed: %s. Reverting to the previous map.", title, strings.Join(reasons, ", "))
+		g.outbox.Send(func() error { return g.Lobby.SetMap(accepted, mode) })
+	} else {
+		g.say("%s isn't allowed: %s. Please pick another map.", title, strings.Join(reasons, ", "))
+	}
+
+	if g.maxViolations > 0 && g.violations >= g.maxViolations {
+		g.violations = 0
+		if host := g.Lobby.Host(); host != nil {
+			g.say("%s picked %d disallowed maps in a row and was skipped", host.User.Name(), g.maxViolations)
+		}
+		g.outbox.Send(g.skipHost)
+	}
+}
+
+// nextSlotHost gives host to the player in the next occupied slot after the current host
+func (g *BeatmapGuard) nextSlotHost() error {
+	players := g.Lobby.Players()
+	if len(players) < 2 {
+		return nil
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Slot < players[j].Slot })
+
+	next := players[0]
+	if host := g.Lobby.Host(); host != nil {
+		for _, p := range players {
+			if p.Slot > host.Slot {
+				next = p
+				break
+			}
+		}
+	}
+	return g.Lobby.SetHost(next.User)
+}
+
+// say queues a message to the lobby, must be called with g.mu locked
+func (g *BeatmapGuard) say(format string, a ...any) {
+	text := fmt.Sprintf(format, a...)
+	g.outbox.Send(func() error { return g.Lobby.SendMessage(text) })
+}
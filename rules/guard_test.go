@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/banchotest"
+)
+
+func TestBeatmapGuard(t *testing.T) {
+	client, conn := banchotest.NewClient(t, banchogo.ClientOptions{})
+
+	skipped := make(chan struct{}, 1)
+	g := NewBeatmapGuard(client.GetLobby(1), BeatmapGuardOptions{
+		Rules:         BeatmapRules{MaxStars: 5, BannedMaps: []int{666}},
+		MaxViolations: 2,
+		SkipHost:      func() error { skipped <- struct{}{}; return nil },
+	})
+	g.Start()
+	defer g.Stop()
+
+	g.onBeatmapChanged(1, "A - B [Easy]")
+	g.onBeatmapResolved(&banchogo.Beatmap{ID: 1, Artist: "A", Title: "B", Version: "Easy", StarRating: 2})
+
+	g.onBeatmapChanged(2, "A - B [Extra]")
+	g.onBeatmapResolved(&banchogo.Beatmap{ID: 2, Artist: "A", Title: "B", Version: "Extra", StarRating: 6})
+	// The guard reverts the map, the change back isn't counted as a pick
+	g.onBeatmapChanged(1, "A - B [Easy]")
+	g.onBeatmapResolved(&banchogo.Beatmap{ID: 1, Artist: "A", Title: "B", Version: "Easy", StarRating: 2})
+
+	g.onBeatmapChanged(666, "C - D [Hard]")
+
+	sent := banchotest.WaitSent(t, conn, 4)
+	want := []string{
+		"PRIVMSG #mp_1 :A - B [Extra] isn't allowed: 6.00* is above the 5.00* limit. Reverting to the previous map.",
+		"PRIVMSG #mp_1 :!mp map 1 0",
+		"PRIVMSG #mp_1 :C - D [Hard] isn't allowed: this map is banned. Reverting to the previous map.",
+		"PRIVMSG #mp_1 :!mp map 1 0",
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+	select {
+	case <-skipped:
+	case <-time.After(5 * time.Second):
+		t.Error("host should be skipped after 2 violations")
+	}
+}
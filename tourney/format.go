@@ -0,0 +1,60 @@
+package tourney
+
+import "github.com/robloxxa/banchogo"
+
+// Order decides which team makes the first action in a phase
+type Order int
+
+const (
+	RollWinner Order = iota
+	RollLoser
+)
+
+// Format describes match procedure
+type Format struct {
+	// BestOf is a maximum number of played maps, e.g. 7 means the first team with 4 points wins
+	BestOf int
+
+	// Warmups, Protects and Bans are counts per team
+	Warmups  int
+	Protects int
+	Bans     int
+
+	FirstProtect Order
+	FirstBan     Order
+	FirstPick    Order
+
+	// TiebreakerSlot is picked automatically when both teams are one point away from winning, "TB" by default
+	TiebreakerSlot string
+
+	// RollMax is the only accepted "!roll" upper bound, banchogo.DefaultRollMax by default
+	RollMax int
+
+	// ManualStart disables starting the match when all players are ready
+	ManualStart bool
+}
+
+// PointsToWin returns points required to win the match
+func (f Format) PointsToWin() int {
+	return f.BestOf/2 + 1
+}
+
+// Team is a match side. In 1v1 matches team has a single player which is also a captain.
+type Team struct {
+	Name    string
+	Captain *banchogo.User
+	Players []*banchogo.User
+}
+
+// Has reports whether user plays for the team
+func (t *Team) Has(u *banchogo.User) bool {
+	if t.Captain == u {
+		return true
+	}
+	for _, p := range t.Players {
+		if p == u {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,551 @@
+// Package tourney drives a referee lobby through a tournament match:
+// warmups, rolls, protects, bans, picks, point tracking and a tiebreaker.
+//
+// Captains act by sending plain chat messages to the lobby: "!roll" to roll
+// and slot names like "NM2" to protect, ban or pick a map.
+package tourney
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/robloxxa/banchogo/internal/outbox"
+	"github.com/robloxxa/banchogo/router"
+)
+
+var (
+	ErrNotYourTurn   = errors.New("not your turn")
+	ErrUnknownSlot   = errors.New("unknown slot")
+	ErrSlotProtected = errors.New("map is protected")
+	ErrSlotBanned    = errors.New("map is banned")
+	ErrSlotPicked    = errors.New("map was already picked")
+	ErrSlotProtect   = errors.New("map is already protected")
+	ErrTiebreaker    = errors.New("tiebreaker can't be protected, banned or picked")
+)
+
+type Phase int
+
+const (
+	PhaseWarmup Phase = iota
+	PhaseRoll
+	PhaseProtect
+	PhaseBan
+	PhasePick
+	PhasePlaying
+	PhaseTiebreaker
+	PhaseFinished
+)
+
+var phaseNames = [...]string{"warmup", "roll", "protect", "ban", "pick", "playing", "tiebreaker", "finished"}
+
+func (p Phase) String() string {
+	if p >= 0 && int(p) < len(phaseNames) {
+		return phaseNames[p]
+	}
+	return strconv.Itoa(int(p))
+}
+
+// State is a snapshot of match progress
+type State struct {
+	Phase  Phase
+	Points [2]int
+	// Turn is an index of the team which acts now in protect, ban and pick phases
+	Turn int
+	// RollWinner is an index of the team which won the roll, -1 if rolls weren't done yet
+	RollWinner int
+
+	Protected [2][]string
+	Banned    [2][]string
+	// Picked are slots in the order they were played, tiebreaker included
+	Picked []string
+	// Current is the map being played, nil between picks
+	Current *banchogo.PoolSlot
+}
+
+type Options struct {
+	Format Format
+	Pool   *banchogo.Mappool
+	// Teams[0] plays as red and Teams[1] as blue
+	Teams [2]*Team
+	// OnUpdate is called after every state change
+	OnUpdate func(State)
+}
+
+// Match is a tournament match procedure engine attached to a referee lobby
+type Match struct {
+	mu sync.Mutex
+
+	Lobby  *banchogo.Lobby
+	Router *router.Router
+	Format Format
+	Pool   *banchogo.Mappool
+	Teams  [2]*Team
+
+	OnUpdate func(State)
+
+	state       State
+	rolls       [2]int
+	warmupsLeft int
+	actions     int
+	pickCount   int
+
+	outbox          *outbox.Outbox
+	handlerRemovers []func()
+}
+
+func New(lobby *banchogo.Lobby, opt Options) *Match {
+	m := &Match{
+		Lobby:    lobby,
+		Format:   opt.Format,
+		Pool:     opt.Pool,
+		Teams:    opt.Teams,
+		OnUpdate: opt.OnUpdate,
+
+		state:       State{RollWinner: -1},
+		warmupsLeft: opt.Format.Warmups * 2,
+	}
+	if m.Format.TiebreakerSlot == "" {
+		m.Format.TiebreakerSlot = "TB"
+	}
+	if m.Format.RollMax <= 0 {
+		m.Format.RollMax = banchogo.DefaultRollMax
+	}
+	if m.warmupsLeft == 0 {
+		m.state.Phase = PhaseRoll
+	}
+
+	m.Router = router.New(router.Options{
+		IsReferee: func(_ *banchogo.Channel, user *banchogo.User) bool {
+			return lobby.IsReferee(user)
+		},
+	})
+	m.Router.MustRegister(&router.Command{
+		Name:        "status",
+		Description: "shows match score and current phase",
+		Handler: func(ctx *router.Context) error {
+			return ctx.Reply(m.statusLine())
+		},
+	})
+	m.Router.MustRegister(&router.Command{
+		Name:        "warmup",
+		Description: "sets a warmup map, captains only",
+		Args:        []router.Arg{{Name: "beatmap", Type: router.ArgBeatmap}},
+		Handler:     m.warmupCommand,
+	})
+	m.Router.MustRegister(&router.Command{
+		Name:        "skipwarmup",
+		Description: "skips remaining warmups",
+		Permission:  router.Referee,
+		Handler: func(ctx *router.Context) error {
+			m.mu.Lock()
+			if m.state.Phase == PhaseWarmup {
+				m.warmupsLeft = 0
+				m.setPhase(PhaseRoll)
+			}
+			m.mu.Unlock()
+			m.update()
+			return nil
+		},
+	})
+
+	return m
+}
+
+// Start subscribes to lobby events and announces the first phase
+func (m *Match) Start() {
+	m.outbox = outbox.New()
+
+	l := m.Lobby
+	m.handlerRemovers = []func(){
+		l.Channel.OnMessage(m.onChannelMessage),
+		l.OnRoll(func(r *banchogo.RollResult) { m.handleRoll(r) }),
+		l.OnMatchFinished(m.onMatchFinished),
+		l.OnAllPlayersReady(m.onAllPlayersReady),
+		m.Router.ListenChannel(l.Client, l.Channel),
+	}
+
+	m.mu.Lock()
+	m.say("%s vs %s, best of %d", m.Teams[0].Name, m.Teams[1].Name, m.Format.BestOf)
+	m.announcePhase()
+	m.mu.Unlock()
+	m.update()
+}
+
+// Stop unsubscribes from lobby events, the match can't be resumed after it
+func (m *Match) Stop() {
+	for _, f := range m.handlerRemovers {
+		f()
+	}
+	m.handlerRemovers = nil
+
+	m.mu.Lock()
+	m.outbox.Close()
+	m.outbox = nil
+	m.mu.Unlock()
+}
+
+// State returns a snapshot of the match state
+func (m *Match) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot()
+}
+
+func (m *Match) snapshot() State {
+	s := m.state
+	s.Protected = [2][]string{append([]string(nil), s.Protected[0]...), append([]string(nil), s.Protected[1]...)}
+	s.Banned = [2][]string{append([]string(nil), s.Banned[0]...), append([]string(nil), s.Banned[1]...)}
+	s.Picked = append([]string(nil), s.Picked...)
+	if s.Current != nil {
+		current := *s.Current
+		s.Current = &current
+	}
+	return s
+}
+
+func (m *Match) update() {
+	if m.OnUpdate != nil {
+		m.OnUpdate(m.State())
+	}
+}
+
+func (m *Match) onChannelMessage(msg *banchogo.ChannelMessage) {
+	if strings.EqualFold(msg.User.Name(), "BanchoBot") {
+		return
+	}
+
+	team := m.captainOf(msg.User)
+	if team == -1 {
+		return
+	}
+
+	slot := strings.ToUpper(strings.TrimSpace(msg.Content()))
+	if _, ok := m.Pool.Slot(slot); !ok {
+		return
+	}
+
+	if err := m.Act(team, slot); err != nil && !errors.Is(err, ErrNotYourTurn) {
+		m.mu.Lock()
+		m.say("%s: %s", slot, err)
+		m.mu.Unlock()
+	}
+}
+
+// Act protects, bans or picks a slot for the team depending on current phase.
+// Captain messages are handled with it, referees can call it to act for a team.
+func (m *Match) Act(team int, slot string) error {
+	m.mu.Lock()
+	defer func() {
+		m.mu.Unlock()
+		m.update()
+	}()
+
+	switch m.state.Phase {
+	case PhaseProtect, PhaseBan, PhasePick:
+	default:
+		return ErrNotYourTurn
+	}
+	if m.state.Turn != team {
+		return ErrNotYourTurn
+	}
+
+	mp, ok := m.Pool.Slot(slot)
+	if !ok {
+		return ErrUnknownSlot
+	}
+	slot = mp.Slot
+	if strings.EqualFold(slot, m.Format.TiebreakerSlot) {
+		return ErrTiebreaker
+	}
+	if m.isBanned(slot) {
+		return ErrSlotBanned
+	}
+	if m.isPicked(slot) {
+		return ErrSlotPicked
+	}
+
+	switch m.state.Phase {
+	case PhaseProtect:
+		if m.isProtected(slot) {
+			return ErrSlotProtect
+		}
+		m.state.Protected[team] = append(m.state.Protected[team], slot)
+		m.say("%s protected %s", m.Teams[team].Name, slot)
+		m.nextAction(m.Format.Protects, PhaseBan)
+	case PhaseBan:
+		if m.isProtected(slot) {
+			return ErrSlotProtected
+		}
+		m.state.Banned[team] = append(m.state.Banned[team], slot)
+		m.say("%s banned %s", m.Teams[team].Name, slot)
+		m.nextAction(m.Format.Bans, PhasePick)
+	case PhasePick:
+		m.say("%s picked %s", m.Teams[team].Name, slot)
+		m.play(mp)
+	}
+	return nil
+}
+
+func (m *Match) handleRoll(r *banchogo.RollResult) {
+	m.mu.Lock()
+	defer func() {
+		m.mu.Unlock()
+		m.update()
+	}()
+
+	team := m.captainOf(r.User)
+	if m.state.Phase != PhaseRoll || team == -1 || m.rolls[team] != 0 {
+		return
+	}
+	// A bigger upper bound would win the roll almost surely
+	if r.Max != m.Format.RollMax {
+		m.say("%s, please roll again with !roll %d", r.User.Name(), m.Format.RollMax)
+		return
+	}
+	m.rolls[team] = r.Value
+	if m.rolls[0] == 0 || m.rolls[1] == 0 {
+		return
+	}
+
+	if m.rolls[0] == m.rolls[1] {
+		m.rolls = [2]int{}
+		m.say("Rolls are tied, captains please roll again")
+		return
+	}
+
+	winner := 0
+	if m.rolls[1] > m.rolls[0] {
+		winner = 1
+	}
+	m.state.RollWinner = winner
+	m.say("%s wins the roll (%d vs %d)", m.Teams[winner].Name, m.rolls[winner], m.rolls[1-winner])
+
+	switch {
+	case m.Format.Protects > 0:
+		m.setPhase(PhaseProtect)
+	case m.Format.Bans > 0:
+		m.setPhase(PhaseBan)
+	default:
+		m.setPhase(PhasePick)
+	}
+}
+
+func (m *Match) onMatchFinished(result *banchogo.MatchResult) {
+	m.mu.Lock()
+	defer func() {
+		m.mu.Unlock()
+		m.update()
+	}()
+
+	switch m.state.Phase {
+	case PhaseWarmup:
+		m.warmupsLeft--
+		if m.warmupsLeft <= 0 {
+			m.setPhase(PhaseRoll)
+		}
+		return
+	case PhasePlaying, PhaseTiebreaker:
+	default:
+		return
+	}
+
+	var totals [2]int
+	for _, s := range result.Scores {
+		if team := m.teamOf(s); team != -1 {
+			totals[team] += s.Score
+		}
+	}
+	if totals[0] == totals[1] {
+		m.say("Scores are tied (%d), the map will be replayed", totals[0])
+		return
+	}
+
+	winner := 0
+	if totals[1] > totals[0] {
+		winner = 1
+	}
+	m.state.Points[winner]++
+	m.say("%s wins %s (%d vs %d) | %s", m.Teams[winner].Name, m.state.Current.Slot, totals[winner], totals[1-winner], m.scoreLine())
+	m.state.Current = nil
+
+	need := m.Format.PointsToWin()
+	switch {
+	case m.state.Points[winner] >= need:
+		m.state.Phase = PhaseFinished
+		m.say("%s wins the match!", m.Teams[winner].Name)
+	case m.state.Points[0] == need-1 && m.state.Points[1] == need-1:
+		tb, ok := m.Pool.Slot(m.Format.TiebreakerSlot)
+		if !ok {
+			m.say("Tiebreaker slot %s isn't in the mappool", m.Format.TiebreakerSlot)
+			return
+		}
+		m.say("Tiebreaker!")
+		m.play(tb)
+		m.state.Phase = PhaseTiebreaker
+	default:
+		m.setPhase(PhasePick)
+	}
+}
+
+func (m *Match) onAllPlayersReady() {
+	m.mu.Lock()
+	start := !m.Format.ManualStart && m.state.Current != nil &&
+		(m.state.Phase == PhasePlaying || m.state.Phase == PhaseTiebreaker)
+	if start {
+		m.outbox.Send(func() error { return m.Lobby.Start() })
+	}
+	m.mu.Unlock()
+}
+
+func (m *Match) warmupCommand(ctx *router.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Phase != PhaseWarmup {
+		return ctx.Reply("Warmups are over")
+	}
+	if m.captainOf(ctx.User) == -1 && !m.Lobby.IsReferee(ctx.User) {
+		return nil
+	}
+
+	id := ctx.Beatmap("beatmap").ID
+	m.outbox.Send(func() error { return m.Lobby.SetMap(id) })
+	return nil
+}
+
+// play applies the slot in the lobby, must be called with m.mu locked
+func (m *Match) play(slot banchogo.PoolSlot) {
+	current := slot
+	m.state.Current = &current
+	m.state.Picked = append(m.state.Picked, slot.Slot)
+	m.state.Phase = PhasePlaying
+	m.pickCount++
+
+	m.outbox.Send(func() error {
+		if err := <-m.Lobby.ApplyPoolSlot(slot); err != nil {
+			return m.Lobby.SendMessage("Couldn't apply " + slot.Slot + ": " + err.Error())
+		}
+		return nil
+	})
+}
+
+// nextAction passes the turn to the other team or moves to the next phase when
+// both teams used all of their actions, must be called with m.mu locked
+func (m *Match) nextAction(perTeam int, next Phase) {
+	m.actions++
+	if m.actions >= perTeam*2 {
+		if next == PhaseBan && m.Format.Bans == 0 {
+			next = PhasePick
+		}
+		m.setPhase(next)
+		return
+	}
+	m.state.Turn = 1 - m.state.Turn
+	m.announcePhase()
+}
+
+// setPhase must be called with m.mu locked
+func (m *Match) setPhase(p Phase) {
+	m.state.Phase = p
+	m.actions = 0
+
+	switch p {
+	case PhaseProtect:
+		m.state.Turn = m.firstTeam(m.Format.FirstProtect)
+	case PhaseBan:
+		m.state.Turn = m.firstTeam(m.Format.FirstBan)
+	case PhasePick:
+		m.state.Turn = (m.firstTeam(m.Format.FirstPick) + m.pickCount) % 2
+	}
+	m.announcePhase()
+}
+
+func (m *Match) firstTeam(o Order) int {
+	if o == RollWinner {
+		return m.state.RollWinner
+	}
+	return 1 - m.state.RollWinner
+}
+
+// announcePhase must be called with m.mu locked
+func (m *Match) announcePhase() {
+	switch m.state.Phase {
+	case PhaseWarmup:
+		m.say("Warmups: captains can set a map with !warmup <link>")
+	case PhaseRoll:
+		m.say("Captains, please !roll")
+	case PhaseProtect:
+		m.say("%s, please protect a map", m.Teams[m.state.Turn].Name)
+	case PhaseBan:
+		m.say("%s, please ban a map", m.Teams[m.state.Turn].Name)
+	case PhasePick:
+		m.say("%s, please pick a map", m.Teams[m.state.Turn].Name)
+	}
+}
+
+func (m *Match) statusLine() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fmt.Sprintf("%s | phase: %s", m.scoreLine(), m.state.Phase)
+}
+
+func (m *Match) scoreLine() string {
+	return fmt.Sprintf("%s %d - %d %s", m.Teams[0].Name, m.state.Points[0], m.state.Points[1], m.Teams[1].Name)
+}
+
+// say queues a message to the lobby, must be called with m.mu locked
+func (m *Match) say(format string, a ...any) {
+	text := fmt.Sprintf(format, a...)
+	m.outbox.Send(func() error { return m.Lobby.SendMessage(text) })
+}
+
+func (m *Match) captainOf(u *banchogo.User) int {
+	for i, t := range m.Teams {
+		if t.Captain == u {
+			return i
+		}
+	}
+	return -1
+}
+
+// teamOf finds a team of the score by player, falling back to lobby team color
+func (m *Match) teamOf(s banchogo.MatchScore) int {
+	for i, t := range m.Teams {
+		if t.Has(s.User) {
+			return i
+		}
+	}
+	switch s.Team {
+	case banchogo.RedTeam:
+		return 0
+	case banchogo.BlueTeam:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func (m *Match) isProtected(slot string) bool {
+	return contains(m.state.Protected[0], slot) || contains(m.state.Protected[1], slot)
+}
+
+func (m *Match) isBanned(slot string) bool {
+	return contains(m.state.Banned[0], slot) || contains(m.state.Banned[1], slot)
+}
+
+func (m *Match) isPicked(slot string) bool {
+	return contains(m.state.Picked, slot)
+}
+
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package tourney
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/robloxxa/banchogo"
+	"github.com/thehowl/go-osuapi"
+)
+
+func TestMatch_Procedure(t *testing.T) {
+	client := banchogo.NewBanchoClient(banchogo.ClientOptions{Username: "ref"})
+	red, blue := client.GetUser("red"), client.GetUser("blue")
+
+	m := New(client.GetLobby(1), Options{
+		Format: Format{BestOf: 3, Bans: 1, Protects: 1, FirstProtect: RollLoser},
+		Pool: &banchogo.Mappool{Slots: []banchogo.PoolSlot{
+			{Slot: "NM1", BeatmapID: 1},
+			{Slot: "NM2", BeatmapID: 2},
+			{Slot: "HD1", BeatmapID: 3, Mods: osuapi.ModHidden},
+			{Slot: "HR1", BeatmapID: 4, Mods: osuapi.ModHardRock},
+			{Slot: "DT1", BeatmapID: 5, Mods: osuapi.ModDoubleTime},
+			{Slot: "TB", BeatmapID: 6, FreeMod: true},
+		}},
+		Teams: [2]*Team{
+			{Name: "Red", Captain: red},
+			{Name: "Blue", Captain: blue},
+		},
+	})
+
+	roll := func(u *banchogo.User, value, max int) {
+		m.handleRoll(&banchogo.RollResult{User: u, Value: value, Max: max})
+	}
+	// A roll with a bigger upper bound doesn't count
+	roll(red, 900, 1000)
+	if s := m.State(); s.RollWinner != -1 || s.Phase != PhaseRoll {
+		t.Fatalf("roll with a custom max shouldn't count, got %+v", s)
+	}
+	roll(red, 30, banchogo.DefaultRollMax)
+	roll(blue, 70, banchogo.DefaultRollMax)
+	if s := m.State(); s.RollWinner != 1 || s.Phase != PhaseProtect || s.Turn != 0 {
+		t.Fatalf("unexpected state after roll %+v", s)
+	}
+
+	if err := m.Act(1, "NM1"); !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("expected not your turn, got %v", err)
+	}
+	mustAct(t, m, 0, "nm1")
+	mustAct(t, m, 1, "HD1")
+
+	if err := m.Act(1, "NM1"); !errors.Is(err, ErrSlotProtected) {
+		t.Errorf("expected protected error, got %v", err)
+	}
+	mustAct(t, m, 1, "HR1")
+	mustAct(t, m, 0, "DT1")
+
+	if err := m.Act(1, "TB"); !errors.Is(err, ErrTiebreaker) {
+		t.Errorf("expected tiebreaker error, got %v", err)
+	}
+	mustAct(t, m, 1, "NM2")
+
+	finish := func(redScore, blueScore int) {
+		m.onMatchFinished(&banchogo.MatchResult{Scores: []banchogo.MatchScore{
+			{User: red, Score: redScore},
+			{User: blue, Score: blueScore},
+		}})
+	}
+
+	finish(100, 200)
+	if s := m.State(); s.Points != [2]int{0, 1} || s.Phase != PhasePick || s.Turn != 0 {
+		t.Fatalf("unexpected state after the first map %+v", s)
+	}
+
+	mustAct(t, m, 0, "NM1")
+	finish(300, 200)
+
+	s := m.State()
+	if s.Phase != PhaseTiebreaker || s.Current == nil || s.Current.Slot != "TB" {
+		t.Fatalf("expected tiebreaker, got %+v", s)
+	}
+
+	finish(100, 100)
+	if s := m.State(); s.Phase != PhaseTiebreaker {
+		t.Fatalf("tied map should be replayed, got %+v", s)
+	}
+
+	finish(100, 101)
+	s = m.State()
+	if s.Phase != PhaseFinished || s.Points != [2]int{1, 2} {
+		t.Errorf("unexpected final state %+v", s)
+	}
+	if !reflect.DeepEqual(s.Picked, []string{"NM2", "NM1", "TB"}) {
+		t.Errorf("unexpected picks %v", s.Picked)
+	}
+}
+
+func mustAct(t *testing.T, m *Match, team int, slot string) {
+	t.Helper()
+	if err := m.Act(team, slot); err != nil {
+		t.Fatalf("team %d couldn't act on %s: %s", team, slot, err)
+	}
+}
@@ -0,0 +1,225 @@
+package banchogo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TranscriptLine is a raw line received from the server, Offset is the time since recording started
+type TranscriptLine struct {
+	Offset time.Duration
+	Line   string
+}
+
+// Transcript is a recorded sequence of raw server lines. In a file every line is
+// written as milliseconds since recording started and the raw line separated by a tab:
+//
+//	0	:cho.ppy.sh 001 bot :Welcome to the osu!Bancho.
+//	1520	:BanchoBot!cho@ppy.sh PRIVMSG #mp_123 :All players are ready
+type Transcript struct {
+	Lines []TranscriptLine
+}
+
+// LoadTranscript reads a transcript file written by TranscriptRecorder
+func LoadTranscript(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTranscript(f)
+}
+
+// ReadTranscript parses a transcript, lines without an offset are replayed immediately after the previous line
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+
+	var offset time.Duration
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if ms, raw, ok := strings.Cut(line, "\t"); ok {
+			v, err := strconv.ParseInt(ms, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("transcript line %d: invalid offset %q", n, ms)
+			}
+			offset = time.Duration(v) * time.Millisecond
+			line = raw
+		}
+		t.Lines = append(t.Lines, TranscriptLine{Offset: offset, Line: line})
+	}
+	return t, scanner.Err()
+}
+
+// TranscriptRecorder writes every raw line received by the client to a writer.
+// Only inbound lines are recorded, so the password sent on connect never gets into a transcript.
+type TranscriptRecorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	now   func() time.Time
+	err   error
+
+	removeHandler func()
+}
+
+// RecordTranscript starts recording client's inbound lines until Stop is called
+func RecordTranscript(b *Client, w io.Writer) *TranscriptRecorder {
+	r := &TranscriptRecorder{w: w, now: time.Now}
+	r.start = r.now()
+	r.removeHandler = b.OnRawMessage(func(splits []string) {
+		r.Record(strings.Join(splits, " "))
+	})
+	return r
+}
+
+// Record writes a line, it is called for every received line and can be used to add lines manually
+func (r *TranscriptRecorder) Record(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.w, "%d\t%s\n", r.now().Sub(r.start).Milliseconds(), line)
+}
+
+// Err returns the first write error, recording stops after it
+func (r *TranscriptRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *TranscriptRecorder) Stop() {
+	r.removeHandler()
+}
+
+// ReplayConn is an in-memory connection which serves transcript lines to the client
+// and collects lines the client sends
+type ReplayConn struct {
+	transcript *Transcript
+	speed      float64
+
+	mu   sync.Mutex
+	next int
+	buf  bytes.Buffer
+	sent []string
+
+	done      chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Conn returns a connection that replays the transcript. Speed scales delays between lines,
+// e.g. 2 replays twice as fast, 0 replays without any delays.
+func (t *Transcript) Conn(speed float64) *ReplayConn {
+	return &ReplayConn{
+		transcript: t,
+		speed:      speed,
+		done:       make(chan struct{}),
+		closed:     make(chan struct{}),
+	}
+}
+
+// Dialer returns a function for ClientOptions.Dial, every connection replays the transcript from the start
+func (t *Transcript) Dialer(speed float64) func(network, address string) (net.Conn, error) {
+	return func(string, string) (net.Conn, error) {
+		return t.Conn(speed), nil
+	}
+}
+
+// Done is closed when the client has handled every line, that is when it asks for more after the last one.
+// The connection stays open after that until it is closed.
+func (c *ReplayConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Sent returns lines written by the client
+func (c *ReplayConn) Sent() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.sent...)
+}
+
+func (c *ReplayConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.buf.Len() == 0 && c.next < len(c.transcript.Lines) {
+		line := c.transcript.Lines[c.next]
+		var delay time.Duration
+		if c.speed > 0 && c.next > 0 {
+			delay = time.Duration(float64(line.Offset-c.transcript.Lines[c.next-1].Offset) / c.speed)
+		}
+		c.next++
+		c.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-c.closed:
+				return 0, io.EOF
+			}
+		}
+
+		c.mu.Lock()
+		c.buf.WriteString(line.Line + "\r\n")
+	}
+
+	if c.buf.Len() > 0 {
+		defer c.mu.Unlock()
+		return c.buf.Read(p)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	<-c.closed
+	return 0, io.EOF
+}
+
+func (c *ReplayConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\r\n"), "\r\n") {
+		c.sent = append(c.sent, line)
+	}
+	return len(p), nil
+}
+
+func (c *ReplayConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *ReplayConn) LocalAddr() net.Addr              { return replayAddr{} }
+func (c *ReplayConn) RemoteAddr() net.Addr             { return replayAddr{} }
+func (c *ReplayConn) SetDeadline(time.Time) error      { return nil }
+func (c *ReplayConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *ReplayConn) SetWriteDeadline(time.Time) error { return nil }
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
@@ -0,0 +1,70 @@
+package banchogo
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTranscript = `0	:cho.ppy.sh 001 bot :Welcome to the osu!Bancho.
+10	:bot!cho@ppy.sh JOIN :#mp_123
+20	:BanchoBot!cho@ppy.sh PRIVMSG #mp_123 :peppy joined in slot 1.
+25	:BanchoBot!cho@ppy.sh PRIVMSG #mp_123 :Beatmap changed to: Kenji Ninuma - DISCO PRINCE [Normal] (https://osu.ppy.sh/b/75)
+:BanchoBot!cho@ppy.sh PRIVMSG #mp_123 :All players are ready
+`
+
+func TestTranscript_Replay(t *testing.T) {
+	transcript, err := ReadTranscript(strings.NewReader(testTranscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transcript.Lines) != 5 || transcript.Lines[4].Offset != 25*time.Millisecond {
+		t.Fatalf("unexpected transcript %+v", transcript.Lines)
+	}
+
+	conn := transcript.Conn(0)
+	b := NewBanchoClient(ClientOptions{
+		Username: "bot",
+		Password: "secret",
+		Dial:     func(string, string) (net.Conn, error) { return conn, nil },
+	})
+	b.RateLimiter = nil
+	lobby := b.GetLobby(123)
+
+	ready := false
+	lobby.OnAllPlayersReady(func() { ready = true })
+
+	var recorded bytes.Buffer
+	recorder := RecordTranscript(b, &recorded)
+
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+
+	select {
+	case <-conn.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("transcript wasn't replayed")
+	}
+	recorder.Stop()
+
+	if !ready || lobby.BeatmapID() != 75 || len(lobby.Players()) != 1 {
+		t.Errorf("lobby state wasn't updated: ready %v, beatmap %d, players %d", ready, lobby.BeatmapID(), len(lobby.Players()))
+	}
+	if sent := conn.Sent(); len(sent) < 3 || sent[0] != "PASS secret" || sent[2] != "NICK bot" {
+		t.Errorf("unexpected sent lines %q", sent)
+	}
+
+	replayed, err := ReadTranscript(&recorded)
+	if err != nil || len(replayed.Lines) != len(transcript.Lines) {
+		t.Fatalf("expected %d recorded lines, got %d (%v)", len(transcript.Lines), len(replayed.Lines), err)
+	}
+	for i, line := range replayed.Lines {
+		if line.Line != transcript.Lines[i].Line {
+			t.Errorf("expected recorded line %q, got %q", transcript.Lines[i].Line, line.Line)
+		}
+	}
+}
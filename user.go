@@ -1,9 +1,12 @@
 package banchogo
 
 import (
+	"context"
+	"github.com/robloxxa/banchogo/apiv2"
 	"github.com/thehowl/go-osuapi"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,15 +16,6 @@ var (
 	whereRegex = regexp.MustCompile("(.+) is in (.+)")
 )
 
-type WhoisResponse struct {
-	user *User
-	c    chan struct{}
-
-	UserId   int
-	Channels []*Channel
-	Error    error
-}
-
 type WhereResponse struct {
 	Country string
 	Error   error
@@ -33,19 +27,20 @@ type User struct {
 	ev     *EventEmitter
 	client *Client
 
-	whoisChan       chan *WhoisResponse
 	handlerRemovers [1]func()
 
+	historyOnce sync.Once
+	history     *History
+
 	ircUsername string
 	data        *osuapi.User
+	modeData    map[osuapi.Mode]*osuapi.User
 }
 
 func newBanchoUser(client *Client, username string) *User {
 	return &User{
 		client:      client,
 		ircUsername: username,
-
-		whoisChan: make(chan *WhoisResponse, 1),
 	}
 }
 
@@ -60,29 +55,81 @@ func (u *User) SendMessage(message string) error {
 }
 
 func (u *User) SendAction(message string) error {
-	return newOutgoingBanchoMessage(u.client, u, "ACTION "+message).Send()
+	return newOutgoingBanchoMessage(u.client, u, EncodeCTCP("ACTION", message)).Send()
 }
 
 func (u *User) Type() string {
 	return "user"
 }
 
+// FetchFromAPI fetches user data with API v2 if it's configured in the client, otherwise with the legacy API.
+// Results are cached per mode in Client.Cache.
 func (u *User) FetchFromAPI(osuMode ...int) (osuapi.User, error) {
-	mode := 0
+	mode := osuapi.ModeOsu
 	if len(osuMode) > 0 {
-		mode = osuMode[0]
+		mode = osuapi.Mode(osuMode[0])
+	}
+
+	var data osuapi.User
+	var err error
+	if u.client.ApiV2 != nil {
+		var v2 *apiv2.User
+		if v2, err = u.FetchFromAPIv2(mode); err == nil {
+			data = *legacyUser(v2)
+		}
+	} else {
+		name := u.Name()
+		data, err = cachedFetch(u.client.Cache, userCacheKey(name, "v1", strconv.Itoa(int(mode))), func() (osuapi.User, error) {
+			d, err := u.client.Api.GetUser(osuapi.GetUserOpts{Username: name, Mode: mode})
+			if err != nil {
+				return osuapi.User{}, err
+			}
+			return *d, nil
+		})
 	}
-	data, err := u.client.Api.GetUser(osuapi.GetUserOpts{Username: u.ircUsername, Mode: osuapi.Mode(mode)})
 	if err != nil {
 		return osuapi.User{}, err
 	}
+
 	u.mu.Lock()
-	u.data = data
+	if u.modeData == nil {
+		u.modeData = make(map[osuapi.Mode]*osuapi.User, 1)
+	}
+	u.data = &data
+	u.modeData[mode] = &data
 	u.mu.Unlock()
-	return *data, nil
+	return data, nil
 }
 
-// Data returns copy of API user data.
+// FetchFromAPIv2 fetches full user data with API v2, mode is user's default mode if omitted.
+// Results are cached per mode in Client.Cache.
+func (u *User) FetchFromAPIv2(mode ...osuapi.Mode) (*apiv2.User, error) {
+	if u.client.ApiV2 == nil {
+		return nil, ErrApiV2NotConfigured
+	}
+
+	var modes []apiv2.Mode
+	cacheMode := "default"
+	if len(mode) > 0 && int(mode[0]) < len(apiv2.Modes) {
+		modes = append(modes, apiv2.Modes[mode[0]])
+		cacheMode = strconv.Itoa(int(mode[0]))
+	}
+
+	name := u.Name()
+	data, err := cachedFetch(u.client.Cache, userCacheKey(name, "v2", cacheMode), func() (apiv2.User, error) {
+		d, err := u.client.ApiV2.GetUserByName(context.Background(), name, modes...)
+		if err != nil {
+			return apiv2.User{}, err
+		}
+		return *d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Data returns copy of the last fetched API user data.
 func (u *User) Data() osuapi.User {
 	u.mu.Lock()
 	defer u.mu.Unlock()
@@ -92,9 +139,19 @@ func (u *User) Data() osuapi.User {
 	return *u.data
 }
 
+// DataFor returns copy of API user data fetched for the mode
+func (u *User) DataFor(mode osuapi.Mode) (osuapi.User, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if d, ok := u.modeData[mode]; ok {
+		return *d, true
+	}
+	return osuapi.User{}, false
+}
+
 func (u *User) on(name string, handler interface{}, once bool) func() {
 	if u.ev == nil {
-		u.ev = &EventEmitter{}
+		u.ev = &EventEmitter{observe: u.client.observeHandler}
 
 		u.handlerRemovers = [1]func(){
 			u.client.OnPrivateMessage(func(m *PrivateMessage) {
@@ -106,7 +163,7 @@ func (u *User) on(name string, handler interface{}, once bool) func() {
 
 		// TODO: Figure out the proper way to clear events when object is gced
 		// Note: SetFinalizer prevent object to be freed when gc tries to free it for the first time
-		runtime.SetFinalizer(u, func() {
+		runtime.SetFinalizer(u, func(u *User) {
 			for _, f := range u.handlerRemovers {
 				f()
 			}
@@ -170,34 +227,10 @@ func (u *User) Where() <-chan WhereResponse {
 		afterResponse()
 	}
 
-	return resp
-}
-
-func (u *User) Whois() <-chan WhoisResponse {
-	resp := make(chan WhoisResponse, 1)
-	whoisObj := &WhoisResponse{user: u, c: make(chan struct{})}
-	go func(response chan WhoisResponse, whoisObj *WhoisResponse) {
-		for {
-			select {
-			case u.whoisChan <- whoisObj:
-				u.whoisChan <- whoisObj
-				err := u.client.Send("WHOIS " + u.ircUsername)
-				if err != nil {
-					resp <- WhoisResponse{Error: err}
-					return
-				}
-			case <-whoisObj.c:
-				resp <- *whoisObj
-			case <-time.After(10 * time.Second):
-				resp <- WhoisResponse{Error: ErrMessageTimeout}
-				return
-			}
-		}
-	}(resp, whoisObj)
-
-	return resp
+	return observeBanchoCommand(u.client, "where", resp, func(r WhereResponse) error { return r.Error })
 }
 
 func (u *User) Stats() <-chan BanchoBotStatsResponse {
-	return newBanchoBotStatsCommand(u).Send()
+	return observeBanchoCommand(u.client, "stats", newBanchoBotStatsCommand(u).Send(),
+		func(r BanchoBotStatsResponse) error { return r.Error })
 }